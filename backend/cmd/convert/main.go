@@ -0,0 +1,78 @@
+// Command convert rewrites JSON cleaner definitions as YAML.
+//
+// Each *.json file in the target directory is decoded and validated exactly as
+// the server would load it, then written next to the original with a .yaml
+// extension. The JSON file is removed afterwards unless -keep is set, so the
+// loader doesn't see the same cleaner ID twice.
+//
+// Usage:
+//
+//	go run ./cmd/convert -dir ./resources
+package main
+
+import (
+	"backend/internal/cleaners"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", "./resources", "directory containing JSON cleaner definitions")
+	keep := flag.Bool("keep", false, "keep the original JSON files")
+	flag.Parse()
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing %s: %v\n", *dir, err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, file := range files {
+		if err := convertFile(file, *keep); err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Converted %s\n", file)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// convertFile writes the YAML version of a single JSON definition.
+func convertFile(file string, keep bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	// refuse to convert anything the server itself wouldn't load
+	if _, err := cleaners.DecodeCleaner(file, data); err != nil {
+		return err
+	}
+
+	out, err := cleaners.JSONToYAML(data)
+	if err != nil {
+		return err
+	}
+
+	target := strings.TrimSuffix(file, filepath.Ext(file)) + ".yaml"
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("%s already exists", target)
+	}
+
+	if err := os.WriteFile(target, out, 0644); err != nil {
+		return err
+	}
+
+	if keep {
+		return nil
+	}
+	return os.Remove(file)
+}
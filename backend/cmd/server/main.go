@@ -1,10 +1,14 @@
 package main
 
 import (
+	"backend/internal/cleaners"
+	"backend/internal/constants"
 	"backend/internal/controller/handlers"
+	"backend/internal/i18n"
 	"backend/internal/logger"
 	"backend/internal/middleware"
 	"backend/internal/routes"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -56,6 +60,21 @@ func main() {
 
 	slog.Info("Environment loaded", "level", logLevel.String())
 
+	// load translations of cleaner definitions and server messages
+	if err := i18n.LoadLocales("./resources/locales"); err != nil {
+		slog.Warn("Error loading locales (using English only)", "error", err)
+	}
+
+	// run detection in the background so the first cleaner listing is served from the cache
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.GetCleanersContextTimeout)
+		defer cancel()
+
+		if err := cleaners.WarmDetectionCache(ctx); err != nil {
+			slog.Warn("Error warming detection cache", "error", err)
+		}
+	}()
+
 	// Set Gin to Release mode if we aren't in debug to keep console clean
 	if logLevel != slog.LevelDebug {
 		gin.SetMode(gin.ReleaseMode)
@@ -76,6 +95,8 @@ func main() {
 		api.POST(routes.Preview, handlers.HandlePreview)
 		api.POST(routes.Clean, handlers.HandleClean)
 		api.POST(routes.Abort, handlers.HandleAbort)
+		api.POST(routes.WipeFreeSpace, handlers.HandleWipeFreeSpace)
+		api.GET(routes.WipeFreeSpace, handlers.GetWipeProgress)
 	}
 
 	// load port from .env file
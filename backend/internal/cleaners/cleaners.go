@@ -1,70 +1,167 @@
 package cleaners
 
 import (
-	"backend/internal/detector"
+	"backend/internal/filesystem"
 	"backend/internal/models"
 	"context"
-	"encoding/json"
 	"log/slog"
-	"os"
 	"path/filepath"
 )
 
+const (
+	// cleanersDir is the directory holding the bundled cleaner definitions
+	cleanersDir = "./resources"
+
+	// importedCleanersDir holds third-party definitions (BleachBit CleanerML
+	// files, winapp2.ini) dropped in by the user. It is optional.
+	importedCleanersDir = "./resources/imported"
+)
+
+// LoadAllCleaners loads the bundled cleaner definitions from the resources directory,
+// followed by any imported definitions. Bundled cleaners win on duplicate IDs.
 func LoadAllCleaners(ctx context.Context) ([]models.Cleaner, error) {
-	cleanersDir := "./resources"
+	templates, err := LoadTemplates(ctx, templatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaners, err := LoadCleanersFromDir(ctx, cleanersDir, templates)
+	if err != nil {
+		return cleaners, err
+	}
 
-	files, err := os.ReadDir(cleanersDir)
+	if !filesystem.Exists(filesystem.Current(), importedCleanersDir) {
+		return cleaners, nil
+	}
+
+	imported, err := LoadCleanersFromDir(ctx, importedCleanersDir, templates)
 	if err != nil {
-		slog.Error("Error reading dir %s: %v\n", cleanersDir, err)
+		return cleaners, err
+	}
+
+	return mergeCleaners(cleaners, imported), nil
+}
+
+// mergeCleaners appends the extra cleaners whose IDs aren't already present in base.
+func mergeCleaners(base []models.Cleaner, extra []models.Cleaner) []models.Cleaner {
+	known := make(map[string]bool, len(base))
+	for _, cleaner := range base {
+		known[cleaner.ID] = true
+	}
+
+	for _, cleaner := range extra {
+		if known[cleaner.ID] {
+			slog.Warn("Imported cleaner shadowed by bundled definition", "id", cleaner.ID)
+			continue
+		}
+		known[cleaner.ID] = true
+		base = append(base, cleaner)
+	}
+
+	return base
+}
+
+// LoadCleanersFromDir loads every .json, .yaml/.yml, .toml, CleanerML .xml and
+// winapp2 .ini definition in dir.
+//
+// Each definition is expanded against templates (see ExpandCleaner) and
+// validated again afterwards. Files that can't be read, parsed, expanded or
+// validated are logged and skipped, as are definitions whose ID was already
+// loaded from an earlier file.
+func LoadCleanersFromDir(ctx context.Context, cleanersDir string, templates map[string]models.Template) ([]models.Cleaner, error) {
+	fsys := filesystem.Current()
+
+	files, err := fsys.ReadDir(cleanersDir)
+	if err != nil {
+		slog.Error("Error reading dir", "dir", cleanersDir, "error", err)
 		return nil, err
 	}
 
 	var cleaners []models.Cleaner
+	loadedFrom := make(map[string]string)
 
 	for _, file := range files {
 		if ctx.Err() != nil {
 			return cleaners, ctx.Err()
 		}
 
-		if filepath.Ext(file.Name()) != ".json" {
+		if file.IsDir() || !IsSupportedFormat(file.Name()) {
 			continue
 		}
 
 		filePath := filepath.Join(cleanersDir, file.Name())
 
-		data, err := os.ReadFile(filePath)
+		data, err := fsys.ReadFile(filePath)
 		if err != nil {
-			slog.Error("Error reading file %s: %v\n", filePath, err)
+			slog.Error("Error reading file", "file", filePath, "error", err)
 			continue
 		}
 
-		var cleaner models.Cleaner
-		if err := json.Unmarshal(data, &cleaner); err != nil {
-			slog.Error("Error parsing file %s: %v\n", filePath, err)
+		decoded, err := DecodeCleaners(file.Name(), data)
+		if err != nil {
+			slog.Error("Error parsing file", "file", filePath, "error", err)
 			continue
 		}
 
-		cleaners = append(cleaners, cleaner)
-		slog.Info("Loaded cleaner %s from %s\n", cleaner.Name, file.Name())
+		for _, cleaner := range decoded {
+			cleaner, err := ExpandCleaner(cleaner, templates)
+			if err == nil {
+				err = ValidateCleaner(cleaner)
+			}
+			if err != nil {
+				slog.Error("Error expanding cleaner", "file", filePath, "error", err)
+				continue
+			}
+
+			if previous, ok := loadedFrom[cleaner.ID]; ok {
+				slog.Error("Duplicate cleaner id, skipping", "id", cleaner.ID, "file", filePath, "first", previous)
+				continue
+			}
+			loadedFrom[cleaner.ID] = filePath
+
+			cleaners = append(cleaners, cleaner)
+			slog.Info("Loaded cleaner", "name", cleaner.Name, "file", file.Name())
+		}
 	}
 
-	slog.Debug("Total cleaners: %d\n", len(cleaners))
+	slog.Debug("Total cleaners", "count", len(cleaners))
 	return cleaners, nil
 }
 
+// FilterOnlyInstalledCleaners keeps the cleaners that are installed on this
+// machine and have at least one option for this platform, annotated as by
+// DetectCleaners. Options that don't apply here are left out.
+func FilterOnlyInstalledCleaners(ctx context.Context, cleaners []models.Cleaner, refresh bool) ([]models.Cleaner, error) {
+	annotated, err := DetectCleaners(ctx, cleaners, refresh)
 
-func FilterOnlyInstalledCleaners(ctx context.Context,cleaners []models.Cleaner) ([]models.Cleaner, error) {
 	var installedCleaners []models.Cleaner
-
-	for _, cleaner := range cleaners {
-		if ctx.Err() != nil {
-			return installedCleaners, ctx.Err()
+	for _, cleaner := range annotated {
+		if !cleaner.Installed || !cleaner.SupportedOnOS {
+			continue
 		}
 
-		if detector.DetectInstalled(cleaner.Detect) {
-			installedCleaners = append(installedCleaners, cleaner)
+		applicable := make([]models.Option, 0, len(cleaner.Options))
+		for _, option := range cleaner.Options {
+			if option.Applicable {
+				applicable = append(applicable, option)
+			}
 		}
+		cleaner.Options = applicable
+
+		installedCleaners = append(installedCleaners, cleaner)
+	}
+
+	return installedCleaners, err
+}
+
+// WarmDetectionCache loads the definitions and runs their detection rules so
+// the first listing is served from the cache
+func WarmDetectionCache(ctx context.Context) error {
+	cleaners, err := LoadAllCleaners(ctx)
+	if err != nil {
+		return err
 	}
 
-	return installedCleaners, nil
+	_, err = DetectCleaners(ctx, cleaners, true)
+	return err
 }
@@ -0,0 +1,80 @@
+package cleaners
+
+import (
+	"backend/internal/constants"
+	"backend/internal/detector"
+	"backend/internal/models"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// detectionEntry is a cached detection result together with the fingerprint
+// of the rule it was computed from
+type detectionEntry struct {
+	result      models.DetectionResult
+	fingerprint uint64
+}
+
+// detectionCache remembers detection results per cleaner ID so listing
+// cleaners doesn't hit the disk and registry on every request.
+//
+// An entry is reused while it is younger than constants.DetectionCacheTTL and
+// the cleaner's detection rule is unchanged. Definitions are re-read on every
+// load, so an edited rule changes the fingerprint and is detected again.
+type detectionCache struct {
+	mutex   sync.Mutex
+	entries map[string]detectionEntry
+}
+
+var detections = &detectionCache{entries: make(map[string]detectionEntry)}
+
+// detect returns the cached result for the cleaner or evaluates its rule.
+// refresh skips the cache and stores the new result.
+func (cache *detectionCache) detect(cleaner models.Cleaner, refresh bool) models.DetectionResult {
+	fingerprint := detectionFingerprint(cleaner.Detect)
+
+	if !refresh {
+		cache.mutex.Lock()
+		entry, ok := cache.entries[cleaner.ID]
+		cache.mutex.Unlock()
+
+		if ok && entry.fingerprint == fingerprint && time.Since(entry.result.DetectedAt) < constants.DetectionCacheTTL {
+			return entry.result
+		}
+	}
+
+	result := detector.Detect(cleaner.Detect)
+	result.DetectedAt = time.Now()
+
+	cache.mutex.Lock()
+	cache.entries[cleaner.ID] = detectionEntry{result: result, fingerprint: fingerprint}
+	cache.mutex.Unlock()
+
+	return result
+}
+
+// prune drops entries of cleaners that are no longer defined
+func (cache *detectionCache) prune(cleaners []models.Cleaner) {
+	defined := make(map[string]bool, len(cleaners))
+	for _, cleaner := range cleaners {
+		defined[cleaner.ID] = true
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	for id := range cache.entries {
+		if !defined[id] {
+			delete(cache.entries, id)
+		}
+	}
+}
+
+// detectionFingerprint hashes a detection rule in its JSON form
+func detectionFingerprint(detection models.Detection) uint64 {
+	data, _ := json.Marshal(detection)
+	hash := fnv.New64a()
+	hash.Write(data)
+	return hash.Sum64()
+}
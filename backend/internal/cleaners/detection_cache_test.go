@@ -0,0 +1,65 @@
+package cleaners
+
+import (
+	"backend/internal/constants"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDetectionCache(t *testing.T) {
+	memory := filesystem.NewMemory()
+	previous := filesystem.Set(memory)
+	t.Cleanup(func() { filesystem.Set(previous) })
+
+	dir := filepath.Join(string(filepath.Separator), "apps", "editor")
+	other := filepath.Join(string(filepath.Separator), "apps", "other")
+	cleaner := models.Cleaner{ID: "editor", Detect: models.Detection{Type: "dir", Paths: []string{dir}}}
+
+	tests := []struct {
+		name    string
+		change  func(cache *detectionCache)
+		refresh bool
+		want    bool
+	}{
+		{"first detection", func(*detectionCache) { memory.MkdirAll(dir) }, false, true},
+		{"cached after uninstall", func(*detectionCache) { memory.Remove(dir) }, false, true},
+		{"refresh bypasses the cache", nil, true, false},
+		{"refreshed result is cached", func(*detectionCache) { memory.MkdirAll(dir) }, false, false},
+		{"expired entry", func(cache *detectionCache) {
+			entry := cache.entries[cleaner.ID]
+			entry.result.DetectedAt = time.Now().Add(-constants.DetectionCacheTTL - time.Second)
+			cache.entries[cleaner.ID] = entry
+		}, false, true},
+		{"edited rule", func(*detectionCache) {
+			memory.Remove(dir)
+			cleaner.Detect.Paths = []string{other}
+			memory.MkdirAll(other)
+		}, false, true},
+		{"edited rule is cached", func(*detectionCache) { memory.Remove(other) }, false, true},
+	}
+
+	cache := &detectionCache{entries: make(map[string]detectionEntry)}
+	for _, test := range tests {
+		if test.change != nil {
+			test.change(cache)
+		}
+		if result := cache.detect(cleaner, test.refresh); result.Installed != test.want {
+			t.Errorf("%s: installed %v (rule %q), want %v", test.name, result.Installed, result.Rule, test.want)
+		}
+	}
+}
+
+func TestDetectionCachePrune(t *testing.T) {
+	cache := &detectionCache{entries: make(map[string]detectionEntry)}
+	for _, id := range []string{"kept", "removed"} {
+		cache.detect(models.Cleaner{ID: id, Detect: models.Detection{Type: "always"}}, false)
+	}
+
+	cache.prune([]models.Cleaner{{ID: "kept"}})
+	if _, ok := cache.entries["removed"]; ok || len(cache.entries) != 1 {
+		t.Errorf("entries after prune: %v", cache.entries)
+	}
+}
@@ -0,0 +1,185 @@
+package cleaners
+
+import (
+	"backend/internal/importer"
+	"backend/internal/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// formatConverters maps a definition file extension to a function that turns
+// the raw file contents into JSON.
+//
+// Every format is normalised to JSON before decoding, so the json tags on
+// models.Cleaner stay the single source of truth for field names and all
+// formats go through exactly the same decoding and validation.
+var formatConverters = map[string]func([]byte) ([]byte, error){
+	".json": func(data []byte) ([]byte, error) { return data, nil },
+	".yaml": yaml.YAMLToJSON,
+	".yml":  yaml.YAMLToJSON,
+	".toml": tomlToJSON,
+	".xml":  cleanerMLToJSON,
+}
+
+// winapp2Ext is the extension of winapp2.ini files, which hold many cleaners each
+const winapp2Ext = ".ini"
+
+// cleanerOutputFields and optionOutputFields are the keys of models.Cleaner and
+// models.Option that are filled in when cleaners are listed. They share the
+// structs with the definition fields, so decoding alone would accept them.
+var (
+	cleanerOutputFields = []string{"detected", "installed", "supported_on_os", "reason", "reason_id"}
+	optionOutputFields  = []string{"applicable", "reason", "reason_id"}
+)
+
+// IsSupportedFormat reports whether the file name has an extension that
+// LoadCleanersFromDir knows how to decode.
+func IsSupportedFormat(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	_, ok := formatConverters[ext]
+	return ok || ext == winapp2Ext
+}
+
+// DecodeCleaners parses a definition file that may hold several cleaners.
+//
+// winapp2.ini files are imported entry by entry; entries that fail validation
+// are logged and left out. Every other format holds exactly one cleaner and is
+// handled by DecodeCleaner.
+func DecodeCleaners(fileName string, data []byte) ([]models.Cleaner, error) {
+	if strings.ToLower(filepath.Ext(fileName)) != winapp2Ext {
+		cleaner, err := DecodeCleaner(fileName, data)
+		if err != nil {
+			return nil, err
+		}
+		return []models.Cleaner{cleaner}, nil
+	}
+
+	imported, warnings, err := importer.ParseWinapp2(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, warning := range warnings {
+		slog.Warn("winapp2.ini import", "file", fileName, "warning", warning)
+	}
+
+	cleaners := make([]models.Cleaner, 0, len(imported))
+	for _, cleaner := range imported {
+		if err := ValidateCleaner(cleaner); err != nil {
+			slog.Warn("winapp2.ini import", "file", fileName, "error", err)
+			continue
+		}
+		cleaners = append(cleaners, cleaner)
+	}
+
+	return cleaners, nil
+}
+
+// DecodeCleaner parses a cleaner definition in the format implied by fileName's
+// extension (.json, .yaml, .yml, .toml or CleanerML .xml) and validates the result.
+func DecodeCleaner(fileName string, data []byte) (models.Cleaner, error) {
+	var cleaner models.Cleaner
+
+	ext := strings.ToLower(filepath.Ext(fileName))
+	convert, ok := formatConverters[ext]
+	if !ok {
+		return cleaner, fmt.Errorf("unsupported definition format %q", ext)
+	}
+
+	jsonData, err := convert(data)
+	if err != nil {
+		return cleaner, fmt.Errorf("parse %s: %w", ext, err)
+	}
+
+	// CleanerML documents are converted from a models.Cleaner, which always
+	// carries the output fields
+	if ext != ".xml" {
+		if err := rejectOutputFields(jsonData, cleanerOutputFields); err != nil {
+			return cleaner, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cleaner); err != nil {
+		return cleaner, fmt.Errorf("decode cleaner: %w", err)
+	}
+
+	if err := ValidateCleaner(cleaner); err != nil {
+		return cleaner, err
+	}
+
+	return cleaner, nil
+}
+
+// rejectOutputFields fails when a definition sets one of fields or one of the
+// optionOutputFields of its options. Keys are matched case-insensitively, as
+// encoding/json does when decoding.
+func rejectOutputFields(jsonData []byte, fields []string) error {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &document); err != nil {
+		// not an object; decoding reports it
+		return nil
+	}
+
+	for key := range document {
+		if slices.ContainsFunc(fields, func(field string) bool { return strings.EqualFold(key, field) }) {
+			return fmt.Errorf("%q is filled in when cleaners are listed and can't be set by a definition", key)
+		}
+	}
+
+	var options []map[string]json.RawMessage
+	if json.Unmarshal(document["options"], &options) != nil {
+		return nil
+	}
+	for i, option := range options {
+		for key := range option {
+			if slices.ContainsFunc(optionOutputFields, func(field string) bool { return strings.EqualFold(key, field) }) {
+				return fmt.Errorf("option #%d: %q is filled in when cleaners are listed and can't be set by a definition", i, key)
+			}
+		}
+	}
+	return nil
+}
+
+// tomlToJSON decodes a TOML document into generic values and re-encodes it as JSON.
+func tomlToJSON(data []byte) ([]byte, error) {
+	var document map[string]any
+	if err := toml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// cleanerMLToJSON imports a BleachBit CleanerML document. Constructs the
+// importer can't map are logged as warnings rather than failing the file.
+func cleanerMLToJSON(data []byte) ([]byte, error) {
+	cleaner, warnings, err := importer.ParseCleanerML(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, warning := range warnings {
+		slog.Warn("CleanerML import", "cleaner", cleaner.ID, "warning", warning)
+	}
+	return json.Marshal(cleaner)
+}
+
+// JSONToYAML rewrites a JSON cleaner definition as YAML, keeping the key order
+// of the source document. Strings are single-quoted so Windows paths don't need
+// their backslashes escaped.
+func JSONToYAML(data []byte) ([]byte, error) {
+	var document any
+	if err := yaml.UnmarshalWithOptions(data, &document, yaml.UseOrderedMap()); err != nil {
+		return nil, err
+	}
+	return yaml.MarshalWithOptions(document, yaml.UseSingleQuote(true), yaml.IndentSequence(true))
+}
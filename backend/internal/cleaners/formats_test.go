@@ -0,0 +1,215 @@
+package cleaners
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sampleJSON, sampleYAML and sampleTOML are the same definition in each format
+const sampleJSON = `{
+  "id": "editor",
+  "name": "Editor",
+  "description": "Text editor",
+  "running": false,
+  "detect": {"type": "any", "paths": [], "registry": [], "rules": [
+    {"type": "dir", "paths": ["%AppData%\\Editor"], "registry": []},
+    {"type": "registry", "paths": [], "registry": [{"key": "HKCU\\Software\\Editor", "value": "Path"}]}
+  ]},
+  "options": [
+    {
+      "id": "logs",
+      "label": "Logs",
+      "description": "Old log files",
+      "warning": "Keeps the newest",
+      "actions": [
+        {"command": "delete", "search": "glob", "path": "%AppData%\\Editor\\logs\\*.log", "os": ["windows"],
+         "exclude": ["%AppData%\\Editor\\logs\\keep"], "min_size": "1KB", "keep_newest": 2}
+      ]
+    },
+    {
+      "id": "cache",
+      "label": "Cache",
+      "description": "Cache files",
+      "actions": [{"command": "delete", "search": "walk.files", "path": "$HOME/.cache/editor", "include": ["*.bin"]}],
+      "os": ["linux"]
+    }
+  ],
+  "os": ["windows", "linux"]
+}`
+
+const sampleYAML = `id: editor
+name: Editor
+description: Text editor
+running: false
+detect:
+  type: any
+  paths: []
+  registry: []
+  rules:
+    - type: dir
+      paths: ['%AppData%\Editor']
+      registry: []
+    - type: registry
+      paths: []
+      registry:
+        - key: 'HKCU\Software\Editor'
+          value: Path
+options:
+  - id: logs
+    label: Logs
+    description: Old log files
+    warning: Keeps the newest
+    actions:
+      - command: delete
+        search: glob
+        path: '%AppData%\Editor\logs\*.log'
+        os: [windows]
+        exclude: ['%AppData%\Editor\logs\keep']
+        min_size: 1KB
+        keep_newest: 2
+  - id: cache
+    label: Cache
+    description: Cache files
+    actions:
+      - command: delete
+        search: walk.files
+        path: $HOME/.cache/editor
+        include: ['*.bin']
+    os: [linux]
+os: [windows, linux]
+`
+
+const sampleTOML = `id = "editor"
+name = "Editor"
+description = "Text editor"
+running = false
+os = ["windows", "linux"]
+
+[detect]
+type = "any"
+paths = []
+registry = []
+
+[[detect.rules]]
+type = "dir"
+paths = ['%AppData%\Editor']
+registry = []
+
+[[detect.rules]]
+type = "registry"
+paths = []
+registry = [{ key = 'HKCU\Software\Editor', value = "Path" }]
+
+[[options]]
+id = "logs"
+label = "Logs"
+description = "Old log files"
+warning = "Keeps the newest"
+
+[[options.actions]]
+command = "delete"
+search = "glob"
+path = '%AppData%\Editor\logs\*.log'
+os = ["windows"]
+exclude = ['%AppData%\Editor\logs\keep']
+min_size = "1KB"
+keep_newest = 2
+
+[[options]]
+id = "cache"
+label = "Cache"
+description = "Cache files"
+os = ["linux"]
+
+[[options.actions]]
+command = "delete"
+search = "walk.files"
+path = "$HOME/.cache/editor"
+include = ["*.bin"]
+`
+
+func TestDecodeCleanerFormatsAgree(t *testing.T) {
+	want, err := DecodeCleaner("editor.json", []byte(sampleJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Options[0].Actions[0].MinSize != 1024 || want.Detect.Rules[1].Registry[0].Key != `HKCU\Software\Editor` {
+		t.Fatalf("JSON decoded wrong: %+v", want)
+	}
+
+	for name, data := range map[string]string{"editor.yaml": sampleYAML, "editor.yml": sampleYAML, "editor.toml": sampleTOML} {
+		t.Run(name, func(t *testing.T) {
+			got, err := DecodeCleaner(name, []byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestJSONToYAMLRoundTrip(t *testing.T) {
+	want, err := DecodeCleaner("editor.json", []byte(sampleJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := JSONToYAML([]byte(sampleJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(converted), "id: ") {
+		t.Errorf("key order not kept:\n%s", converted)
+	}
+	if !strings.Contains(string(converted), `'%AppData%\Editor\logs\*.log'`) {
+		t.Errorf("paths not single-quoted:\n%s", converted)
+	}
+
+	got, err := DecodeCleaner("editor.yaml", converted)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, converted)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDecodeCleanerRejectsOutputFields(t *testing.T) {
+	definition := func(cleanerFields string, optionFields string) string {
+		return `{"id": "app", "name": "App", "description": "", "detect": {"type": "always"}` + cleanerFields +
+			`, "options": [{"id": "cache", "label": "Cache", "description": "", "actions": [
+				{"command": "delete", "search": "file", "path": "/tmp/app.log"}]` + optionFields + `}]}`
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		wantErr  bool
+	}{
+		{"definition fields only", definition(`, "running": true, "os": ["linux"]`, `, "warning": "careful"`), false},
+		{"installed", definition(`, "installed": true`, ""), true},
+		{"supported_on_os", definition(`, "supported_on_os": true`, ""), true},
+		{"reason", definition(`, "reason": "preset"`, ""), true},
+		{"detected", definition(`, "detected": {"installed": true}`, ""), true},
+		{"other case", definition(`, "Installed": true`, ""), true},
+		{"option applicable", definition("", `, "applicable": true`), true},
+		{"option reason", definition("", `, "reason": "preset"`), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeCleaner("app.json", []byte(test.document))
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+
+	yamlDocument := "id: app\nname: App\ndetect: {type: always}\ninstalled: true\noptions: []\n"
+	if _, err := DecodeCleaner("app.yaml", []byte(yamlDocument)); err == nil {
+		t.Error("YAML definition with installed accepted")
+	}
+}
@@ -0,0 +1,100 @@
+package cleaners
+
+import (
+	"backend/internal/detector"
+	"backend/internal/i18n"
+	"backend/internal/models"
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// DetectCleaners annotates every cleaner with its detection result, whether
+// it is installed and supported on this OS, and which options apply, each with
+// the reason when it doesn't. Results are cached per cleaner (see
+// detectionCache); refresh re-runs every rule.
+func DetectCleaners(ctx context.Context, cleaners []models.Cleaner, refresh bool) ([]models.Cleaner, error) {
+	annotated := make([]models.Cleaner, 0, len(cleaners))
+
+	detections.prune(cleaners)
+
+	for _, cleaner := range cleaners {
+		if ctx.Err() != nil {
+			return annotated, ctx.Err()
+		}
+
+		result := detections.detect(cleaner, refresh)
+		cleaner.Detected = &result
+		cleaner.Installed = result.Installed
+		annotateOptions(&cleaner)
+
+		cleaner.Reason, cleaner.ReasonID, cleaner.ReasonArgs = "", "", nil
+		switch {
+		case !cleaner.SupportedOnOS:
+			cleaner.ReasonID, cleaner.ReasonArgs = i18n.MsgReasonNoOptions, []any{platform()}
+		case !cleaner.Installed:
+			cleaner.ReasonID = i18n.MsgReasonNotDetected
+		default:
+			slog.Debug("Cleaner detected", "id", cleaner.ID, "rule", result.Rule, "detected_at", result.DetectedAt)
+		}
+
+		if cleaner.ReasonID != "" {
+			cleaner.Reason = i18n.Message(i18n.DefaultLocale, cleaner.ReasonID, cleaner.ReasonArgs...)
+		}
+
+		annotated = append(annotated, cleaner)
+	}
+
+	return annotated, nil
+}
+
+// platform names this machine's OS and architecture, e.g. "linux/amd64"
+func platform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// SupportsPlatform reports whether os and arch restrictions of a cleaner or
+// option allow this machine
+func SupportsPlatform(osList []string, archList []string) bool {
+	return detector.IsOSSupported(osList) && detector.IsArchSupported(archList)
+}
+
+// annotateOptions marks the options that have at least one action for this
+// platform, honouring the cleaner's and option's own os/arch restrictions, and
+// the cleaner as supported when any option is applicable
+func annotateOptions(cleaner *models.Cleaner) {
+	cleanerSupported := SupportsPlatform(cleaner.OS, cleaner.Arch)
+
+	options := make([]models.Option, len(cleaner.Options))
+	cleaner.SupportedOnOS = false
+
+	for i, option := range cleaner.Options {
+		option.Applicable, option.ReasonID, option.ReasonArgs = false, "", nil
+
+		switch {
+		case !cleanerSupported:
+			option.ReasonID = i18n.MsgReasonCleanerUnavailable
+		case !SupportsPlatform(option.OS, option.Arch):
+			option.ReasonID = i18n.MsgReasonOptionUnavailable
+		default:
+			option.ReasonID = i18n.MsgReasonNoActions
+			for _, action := range option.Actions {
+				if detector.IsOSSupported(action.OS) {
+					option.Applicable, option.ReasonID = true, ""
+					cleaner.SupportedOnOS = true
+					break
+				}
+			}
+		}
+
+		option.Reason = ""
+		if option.ReasonID != "" {
+			option.ReasonArgs = []any{platform()}
+			option.Reason = i18n.Message(i18n.DefaultLocale, option.ReasonID, option.ReasonArgs...)
+		}
+
+		options[i] = option
+	}
+
+	cleaner.Options = options
+}
@@ -0,0 +1,157 @@
+package cleaners
+
+import (
+	"backend/internal/filesystem"
+	"backend/internal/i18n"
+	"backend/internal/models"
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDetectCleaners(t *testing.T) {
+	previous := filesystem.Set(filesystem.NewMemory())
+	t.Cleanup(func() { filesystem.Set(previous) })
+
+	applicable := models.Option{ID: "files", Actions: []models.Action{{Command: "delete"}}}
+	elsewhere := models.Option{ID: "elsewhere", Actions: []models.Action{{Command: "delete", OS: []string{"no-such-os"}}}}
+	missing := models.Detection{Type: "dir", Paths: []string{filepath.Join(string(filepath.Separator), "not", "installed")}}
+
+	tests := []struct {
+		name          string
+		cleaner       models.Cleaner
+		wantInstalled bool
+		wantSupported bool
+		wantReasonID  string
+	}{
+		{"installed and supported", models.Cleaner{Detect: models.Detection{Type: "always"}, Options: []models.Option{applicable, elsewhere}}, true, true, ""},
+		{"not installed", models.Cleaner{Detect: missing, Options: []models.Option{applicable}}, false, true, i18n.MsgReasonNotDetected},
+		{"no options for this OS", models.Cleaner{Detect: models.Detection{Type: "always"}, Options: []models.Option{elsewhere}}, true, false, i18n.MsgReasonNoOptions},
+		{"unsupported wins over not installed", models.Cleaner{Detect: missing, Options: []models.Option{elsewhere}}, false, false, i18n.MsgReasonNoOptions},
+		{"no options at all", models.Cleaner{Detect: models.Detection{Type: "always"}}, true, false, i18n.MsgReasonNoOptions},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.cleaner.ID = test.name
+			annotated, err := DetectCleaners(context.Background(), []models.Cleaner{test.cleaner}, true)
+			if err != nil || len(annotated) != 1 {
+				t.Fatalf("got %d cleaners, %v", len(annotated), err)
+			}
+
+			cleaner := annotated[0]
+			if cleaner.Detected == nil || cleaner.Installed != cleaner.Detected.Installed {
+				t.Errorf("detection result %+v doesn't match installed %v", cleaner.Detected, cleaner.Installed)
+			}
+			if cleaner.Installed != test.wantInstalled || cleaner.SupportedOnOS != test.wantSupported {
+				t.Errorf("installed %v, supported_on_os %v, want %v and %v", cleaner.Installed, cleaner.SupportedOnOS, test.wantInstalled, test.wantSupported)
+			}
+			if cleaner.ReasonID != test.wantReasonID {
+				t.Errorf("reason_id %q, want %q", cleaner.ReasonID, test.wantReasonID)
+			}
+			if want := i18n.Message(i18n.DefaultLocale, cleaner.ReasonID, cleaner.ReasonArgs...); test.wantReasonID != "" && cleaner.Reason != want {
+				t.Errorf("reason %q, want %q", cleaner.Reason, want)
+			}
+			if test.wantReasonID == "" && cleaner.Reason != "" {
+				t.Errorf("unexpected reason %q", cleaner.Reason)
+			}
+		})
+	}
+}
+
+func TestDetectCleanersKeepsInput(t *testing.T) {
+	input := []models.Cleaner{{
+		ID:      "app",
+		Detect:  models.Detection{Type: "always"},
+		Options: []models.Option{{ID: "files", Actions: []models.Action{{Command: "delete", OS: []string{"no-such-os"}}}}},
+	}}
+
+	if _, err := DetectCleaners(context.Background(), input, true); err != nil {
+		t.Fatal(err)
+	}
+	if input[0].Detected != nil || input[0].Reason != "" || input[0].Options[0].ReasonID != "" {
+		t.Errorf("input cleaner was annotated: %+v", input[0])
+	}
+}
+
+func TestDetectCleanersCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	annotated, err := DetectCleaners(ctx, []models.Cleaner{{ID: "app", Detect: models.Detection{Type: "always"}}}, true)
+	if err != context.Canceled || len(annotated) != 0 {
+		t.Errorf("got %d cleaners, %v; want context.Canceled", len(annotated), err)
+	}
+}
+
+func TestSupportsPlatform(t *testing.T) {
+	tests := []struct {
+		name   string
+		osList []string
+		arch   []string
+		want   bool
+	}{
+		{"no restrictions", nil, nil, true},
+		{"this OS", []string{"no-such-os", runtime.GOOS}, nil, true},
+		{"OS in other case", []string{strings.ToUpper(runtime.GOOS)}, nil, true},
+		{"other OS", []string{"no-such-os"}, nil, false},
+		{"this arch", nil, []string{runtime.GOARCH}, true},
+		{"other arch", nil, []string{"no-such-arch"}, false},
+		{"this OS on another arch", []string{runtime.GOOS}, []string{"no-such-arch"}, false},
+		{"this OS and arch", []string{runtime.GOOS}, []string{"no-such-arch", runtime.GOARCH}, true},
+	}
+
+	for _, test := range tests {
+		if got := SupportsPlatform(test.osList, test.arch); got != test.want {
+			t.Errorf("%s: SupportsPlatform(%v, %v) = %v, want %v", test.name, test.osList, test.arch, got, test.want)
+		}
+	}
+}
+
+func TestAnnotateOptions(t *testing.T) {
+	here := []models.Action{{Command: "delete"}}
+	other := []string{"no-such-os"}
+	otherArch := []string{"no-such-arch"}
+
+	tests := []struct {
+		name          string
+		cleaner       models.Cleaner
+		wantSupported bool
+		wantReasonIDs []string // per option; "" when applicable
+	}{
+		{"unrestricted", models.Cleaner{Options: []models.Option{{Actions: here}}}, true, []string{""}},
+		{"cleaner on this platform", models.Cleaner{OS: []string{runtime.GOOS}, Arch: []string{runtime.GOARCH}, Options: []models.Option{{Actions: here}}}, true, []string{""}},
+		{"cleaner for another OS", models.Cleaner{OS: other, Options: []models.Option{{Actions: here}, {Actions: here}}}, false, []string{i18n.MsgReasonCleanerUnavailable, i18n.MsgReasonCleanerUnavailable}},
+		{"cleaner for another arch", models.Cleaner{Arch: otherArch, Options: []models.Option{{Actions: here}}}, false, []string{i18n.MsgReasonCleanerUnavailable}},
+		{"option for another OS", models.Cleaner{Options: []models.Option{{OS: other, Actions: here}, {Actions: here}}}, true, []string{i18n.MsgReasonOptionUnavailable, ""}},
+		{"option for another arch", models.Cleaner{Options: []models.Option{{Arch: otherArch, Actions: here}}}, false, []string{i18n.MsgReasonOptionUnavailable}},
+		{"option on this platform", models.Cleaner{Options: []models.Option{{OS: []string{runtime.GOOS}, Arch: []string{runtime.GOARCH}, Actions: here}}}, true, []string{""}},
+		{"no action for this OS", models.Cleaner{Options: []models.Option{{Actions: []models.Action{{OS: other}}}, {}}}, false, []string{i18n.MsgReasonNoActions, i18n.MsgReasonNoActions}},
+		{"one action for this OS", models.Cleaner{Options: []models.Option{{Actions: []models.Action{{OS: other}, {OS: []string{runtime.GOOS}}}}}}, true, []string{""}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cleaner := test.cleaner
+			annotateOptions(&cleaner)
+
+			if cleaner.SupportedOnOS != test.wantSupported {
+				t.Errorf("supported_on_os %v, want %v", cleaner.SupportedOnOS, test.wantSupported)
+			}
+			for i, option := range cleaner.Options {
+				want := test.wantReasonIDs[i]
+				if option.Applicable != (want == "") || option.ReasonID != want {
+					t.Errorf("option %d: applicable %v, reason_id %q, want %q", i, option.Applicable, option.ReasonID, want)
+				}
+				if want != "" && option.Reason != i18n.Message(i18n.DefaultLocale, want, platform()) {
+					t.Errorf("option %d: reason %q", i, option.Reason)
+				}
+			}
+			if len(test.cleaner.Options) > 0 && test.cleaner.Options[0].ReasonID != "" {
+				t.Error("annotateOptions changed the caller's options")
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package cleaners
+
+import (
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// templatesDir holds the option templates cleaner definitions can refer to
+const templatesDir = "./resources/templates"
+
+// placeholderPattern matches {{name}} variable references. Braces are used
+// instead of $name so they can't be confused with environment variables.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// LoadTemplates loads every option template in dir, keyed by template ID.
+// A missing directory simply means there are no templates.
+func LoadTemplates(ctx context.Context, dir string) (map[string]models.Template, error) {
+	templates := make(map[string]models.Template)
+
+	fsys := filesystem.Current()
+
+	files, err := fsys.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return templates, nil
+	}
+	if err != nil {
+		slog.Error("Error reading dir", "dir", dir, "error", err)
+		return nil, err
+	}
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return templates, ctx.Err()
+		}
+
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		convert, ok := formatConverters[ext]
+		if file.IsDir() || !ok || ext == ".xml" {
+			continue
+		}
+
+		filePath := filepath.Join(dir, file.Name())
+
+		data, err := fsys.ReadFile(filePath)
+		if err != nil {
+			slog.Error("Error reading file", "file", filePath, "error", err)
+			continue
+		}
+
+		jsonData, err := convert(data)
+		if err != nil {
+			slog.Error("Error parsing template", "file", filePath, "error", err)
+			continue
+		}
+
+		if err := rejectOutputFields(jsonData, nil); err != nil {
+			slog.Error("Error parsing template", "file", filePath, "error", err)
+			continue
+		}
+
+		var template models.Template
+		decoder := json.NewDecoder(bytes.NewReader(jsonData))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&template); err != nil {
+			slog.Error("Error parsing template", "file", filePath, "error", err)
+			continue
+		}
+
+		if template.ID == "" {
+			slog.Error("Template has no id", "file", filePath)
+			continue
+		}
+
+		templates[template.ID] = template
+		slog.Debug("Loaded template", "id", template.ID, "file", file.Name())
+	}
+
+	return templates, nil
+}
+
+// ExpandCleaner resolves a definition's templates and variables.
+//
+// Template options are added in the order the templates are listed, followed
+// by the cleaner's own options; an own option with the same ID as a template
+// option replaces it in place. The first template's profile discovery is used
+// if the cleaner has none of its own. Every {{name}} placeholder in names, descriptions,
+// detection rules and action paths is then replaced with the cleaner's variable
+// of that name, except {{profile}} which is resolved when the cleaner is run
+// (see detector.DiscoverProfiles). Template translations are merged and
+// expanded the same way. Unknown templates and undefined variables are errors.
+//
+// The returned cleaner has Templates and Variables cleared, so it is exactly
+// what the API serves.
+func ExpandCleaner(cleaner models.Cleaner, templates map[string]models.Template) (models.Cleaner, error) {
+	if len(cleaner.Templates) == 0 && len(cleaner.Variables) == 0 {
+		return cleaner, nil
+	}
+
+	var options []models.Option
+	position := make(map[string]int)
+
+	addOption := func(option models.Option) {
+		if i, ok := position[option.ID]; ok {
+			options[i] = option
+			return
+		}
+		position[option.ID] = len(options)
+		options = append(options, option)
+	}
+
+	profiles := cleaner.Profiles
+	for _, templateID := range cleaner.Templates {
+		template, ok := templates[templateID]
+		if !ok {
+			return cleaner, fmt.Errorf("cleaner %q: unknown template %q", cleaner.ID, templateID)
+		}
+		if profiles == nil {
+			profiles = template.Profiles
+		}
+		for _, option := range template.Options {
+			addOption(option)
+		}
+	}
+	for _, option := range cleaner.Options {
+		addOption(option)
+	}
+
+	translations := mergeTemplateTranslations(cleaner, templates)
+
+	var missing []string
+	expand := func(value string) string {
+		return placeholderPattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			if "{{"+name+"}}" == detector.ProfilePlaceholder {
+				// resolved per discovered profile when the cleaner is run
+				return detector.ProfilePlaceholder
+			}
+			replacement, ok := cleaner.Variables[name]
+			if !ok {
+				missing = append(missing, name)
+				return placeholder
+			}
+			return replacement
+		})
+	}
+
+	expanded := cleaner
+	expanded.Name = expand(cleaner.Name)
+	expanded.Description = expand(cleaner.Description)
+	expanded.Detect = expandDetection(cleaner.Detect, expand)
+	if profiles != nil {
+		expandedProfiles := *profiles
+		expandedProfiles.Root = expand(profiles.Root)
+		expanded.Profiles = &expandedProfiles
+	}
+
+	expanded.Options = make([]models.Option, len(options))
+	for i, option := range options {
+		option.Label = expand(option.Label)
+		option.Description = expand(option.Description)
+		option.Warning = expand(option.Warning)
+
+		actions := make([]models.Action, len(option.Actions))
+		for j, action := range option.Actions {
+			action.Path = expand(action.Path)
+			action.Include = expandAll(action.Include, expand)
+			action.Exclude = expandAll(action.Exclude, expand)
+			actions[j] = action
+		}
+		option.Actions = actions
+
+		expanded.Options[i] = option
+	}
+
+	if translations != nil {
+		expanded.Translations = make(map[string]models.CleanerTranslation, len(translations))
+		for locale, translation := range translations {
+			translation.Name = expand(translation.Name)
+			translation.Description = expand(translation.Description)
+
+			optionTranslations := make(map[string]models.OptionTranslation, len(translation.Options))
+			for optionID, optionTranslation := range translation.Options {
+				optionTranslation.Label = expand(optionTranslation.Label)
+				optionTranslation.Description = expand(optionTranslation.Description)
+				optionTranslation.Warning = expand(optionTranslation.Warning)
+				optionTranslations[optionID] = optionTranslation
+			}
+			translation.Options = optionTranslations
+
+			expanded.Translations[locale] = translation
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return cleaner, fmt.Errorf("cleaner %q: undefined variables %v", cleaner.ID, slices.Compact(missing))
+	}
+
+	expanded.Templates = nil
+	expanded.Variables = nil
+	return expanded, nil
+}
+
+// expandDetection applies expand to the paths and registry keys of a
+// detection rule and all of its nested rules
+func expandDetection(detection models.Detection, expand func(string) string) models.Detection {
+	detection.Paths = expandAll(detection.Paths, expand)
+
+	registry := make([]models.RegistryCheck, len(detection.Registry))
+	for i, check := range detection.Registry {
+		check.Key = expand(check.Key)
+		registry[i] = check
+	}
+	detection.Registry = registry
+
+	if detection.Rules != nil {
+		rules := make([]models.Detection, len(detection.Rules))
+		for i, rule := range detection.Rules {
+			rules[i] = expandDetection(rule, expand)
+		}
+		detection.Rules = rules
+	}
+
+	return detection
+}
+
+// mergeTemplateTranslations combines the option translations of the cleaner's
+// templates with its own translations. As with options, the cleaner's own
+// translation of an option replaces the template's.
+func mergeTemplateTranslations(cleaner models.Cleaner, templates map[string]models.Template) map[string]models.CleanerTranslation {
+	var merged map[string]models.CleanerTranslation
+
+	merge := func(source map[string]models.CleanerTranslation, includeCleanerStrings bool) {
+		for locale, translation := range source {
+			if merged == nil {
+				merged = make(map[string]models.CleanerTranslation)
+			}
+
+			current := merged[locale]
+			if includeCleanerStrings {
+				current.Name = translation.Name
+				current.Description = translation.Description
+			}
+			for optionID, optionTranslation := range translation.Options {
+				if current.Options == nil {
+					current.Options = make(map[string]models.OptionTranslation)
+				}
+				current.Options[optionID] = optionTranslation
+			}
+			merged[locale] = current
+		}
+	}
+
+	for _, templateID := range cleaner.Templates {
+		merge(templates[templateID].Translations, false)
+	}
+	merge(cleaner.Translations, true)
+
+	return merged
+}
+
+// expandAll applies expand to every value, returning a new slice
+func expandAll(values []string, expand func(string) string) []string {
+	if values == nil {
+		return nil
+	}
+
+	result := make([]string, len(values))
+	for i, value := range values {
+		result[i] = expand(value)
+	}
+	return result
+}
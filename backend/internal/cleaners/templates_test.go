@@ -0,0 +1,164 @@
+package cleaners
+
+import (
+	"backend/internal/models"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandCleanerOptionOrder(t *testing.T) {
+	option := func(id string, label string) models.Option {
+		return models.Option{ID: id, Label: label, Actions: []models.Action{{Command: "delete", Search: "file", Path: "{{root}}/" + id}}}
+	}
+	templates := map[string]models.Template{
+		"first":  {ID: "first", Options: []models.Option{option("a", "first a"), option("b", "first b")}},
+		"second": {ID: "second", Options: []models.Option{option("b", "second b"), option("c", "second c")}},
+	}
+	cleaner := models.Cleaner{
+		ID:        "app",
+		Variables: map[string]string{"root": "/app"},
+		Templates: []string{"first", "second"},
+		Options:   []models.Option{option("d", "own d"), option("c", "own c")},
+	}
+
+	expanded, err := ExpandCleaner(cleaner, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, option := range expanded.Options {
+		got = append(got, option.Label+" "+option.Actions[0].Path)
+	}
+	want := []string{"first a /app/a", "second b /app/b", "own c /app/c", "own d /app/d"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if expanded.Templates != nil || expanded.Variables != nil {
+		t.Errorf("templates %v and variables %v not cleared", expanded.Templates, expanded.Variables)
+	}
+
+	cleaner.Templates = []string{"first", "third"}
+	if _, err := ExpandCleaner(cleaner, templates); err == nil || !strings.Contains(err.Error(), `"third"`) {
+		t.Errorf("unknown template: %v", err)
+	}
+}
+
+func TestExpandCleanerUndefinedVariables(t *testing.T) {
+	cleaner := models.Cleaner{
+		ID:        "app",
+		Name:      "{{name}}",
+		Variables: map[string]string{"root": "/app"},
+		Profiles:  &models.ProfileDiscovery{Type: "glob", Root: "{{root}}/profiles"},
+		Detect:    models.Detection{Type: "dir", Paths: []string{"{{ missing }}"}},
+		Options: []models.Option{{ID: "cache", Actions: []models.Action{
+			{Command: "delete", Search: "walk.files", Path: "{{root}}/{{profile}}/{{cache}}", Exclude: []string{"{{missing}}"}},
+		}}},
+	}
+
+	_, err := ExpandCleaner(cleaner, nil)
+	if err == nil || !strings.Contains(err.Error(), "[cache missing name]") {
+		t.Fatalf("got %v, want the sorted undefined variables", err)
+	}
+
+	cleaner.Name = "App"
+	cleaner.Detect.Paths = []string{"{{root}}"}
+	cleaner.Options[0].Actions[0].Path = "{{root}}/{{profile}}/Cache"
+	cleaner.Options[0].Actions[0].Exclude = nil
+	expanded, err := ExpandCleaner(cleaner, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path := expanded.Options[0].Actions[0].Path; path != "/app/{{profile}}/Cache" {
+		t.Errorf("path %q, want {{profile}} kept for discovery", path)
+	}
+	if root := expanded.Profiles.Root; root != "/app/profiles" || cleaner.Profiles.Root != "{{root}}/profiles" {
+		t.Errorf("profile root %q, original %q", root, cleaner.Profiles.Root)
+	}
+}
+
+func TestExpandCleanerTranslations(t *testing.T) {
+	templates := map[string]models.Template{
+		"electron": {
+			ID: "electron",
+			Options: []models.Option{
+				{ID: "cache", Label: "Cache", Description: "{{app}} cache"},
+				{ID: "logs", Label: "Logs", Description: "{{app}} logs"},
+			},
+			Translations: map[string]models.CleanerTranslation{
+				"uk": {Name: "template name", Options: map[string]models.OptionTranslation{
+					"cache": {Label: "Кеш", Description: "Кеш {{app}}"},
+					"logs":  {Label: "Журнали", Description: "Журнали {{app}}"},
+				}},
+				"de": {Options: map[string]models.OptionTranslation{"cache": {Label: "Zwischenspeicher"}}},
+			},
+		},
+	}
+	cleaner := models.Cleaner{
+		ID:        "chat",
+		Name:      "Chat",
+		Variables: map[string]string{"app": "Chat"},
+		Templates: []string{"electron"},
+		Translations: map[string]models.CleanerTranslation{
+			"uk": {Name: "Чат", Options: map[string]models.OptionTranslation{"logs": {Label: "Логи {{app}}"}}},
+		},
+	}
+
+	expanded, err := ExpandCleaner(cleaner, templates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]models.CleanerTranslation{
+		"uk": {Name: "Чат", Options: map[string]models.OptionTranslation{
+			"cache": {Label: "Кеш", Description: "Кеш Chat"},
+			"logs":  {Label: "Логи Chat"},
+		}},
+		"de": {Options: map[string]models.OptionTranslation{"cache": {Label: "Zwischenspeicher"}}},
+	}
+	if !reflect.DeepEqual(expanded.Translations, want) {
+		t.Errorf("got %+v, want %+v", expanded.Translations, want)
+	}
+}
+
+// TestTemplatedResourcesMatchOriginals checks that moving definitions onto
+// templates didn't change what they expand to. The originals are in testdata.
+func TestTemplatedResourcesMatchOriginals(t *testing.T) {
+	templates, err := LoadTemplates(context.Background(), filepath.Join("..", "..", "resources", "templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decode := func(path string) models.Cleaner {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cleaner, err := DecodeCleaner(path, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cleaner
+	}
+
+	for _, name := range []string{"vscode.json", "discord.json"} {
+		t.Run(name, func(t *testing.T) {
+			expanded, err := ExpandCleaner(decode(filepath.Join("..", "..", "resources", name)), templates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// translations came with localization and have no original
+			expanded.Translations = nil
+
+			if original := decode(filepath.Join("testdata", name)); !reflect.DeepEqual(expanded, original) {
+				t.Errorf("expanded %+v\noriginal %+v", expanded, original)
+			}
+		})
+	}
+}
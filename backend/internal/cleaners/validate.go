@@ -0,0 +1,273 @@
+package cleaners
+
+import (
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ValidateCleaner checks that a decoded definition is usable: the cleaner and
+// each of its options have IDs, option IDs are unique, every action has a path
+// and a known command, detection rules are well-formed and {{profile}} paths are only used by
+// cleaners that can discover profiles.
+//
+// It is applied to every definition regardless of the format it was loaded from.
+func ValidateCleaner(cleaner models.Cleaner) error {
+	if cleaner.ID == "" {
+		return errors.New("cleaner has no id")
+	}
+
+	if cleaner.Name == "" {
+		return fmt.Errorf("cleaner %q has no name", cleaner.ID)
+	}
+
+	if err := validateDetection(cleaner.Detect); err != nil {
+		return fmt.Errorf("cleaner %q: detect: %w", cleaner.ID, err)
+	}
+
+	if cleaner.Profiles != nil {
+		switch cleaner.Profiles.Type {
+		case "glob", "chromium", "firefox":
+		default:
+			return fmt.Errorf("cleaner %q: unknown profile discovery type %q", cleaner.ID, cleaner.Profiles.Type)
+		}
+		if cleaner.Profiles.Root == "" {
+			return fmt.Errorf("cleaner %q: profile discovery has no root", cleaner.ID)
+		}
+	}
+
+	// templates may still bring in the profile discovery before expansion
+	canDiscoverProfiles := cleaner.Profiles != nil || len(cleaner.Templates) > 0
+
+	seenOptions := make(map[string]bool, len(cleaner.Options))
+	for i, option := range cleaner.Options {
+		if option.ID == "" {
+			return fmt.Errorf("cleaner %q: option #%d has no id", cleaner.ID, i)
+		}
+
+		if seenOptions[option.ID] {
+			return fmt.Errorf("cleaner %q: duplicate option id %q", cleaner.ID, option.ID)
+		}
+		seenOptions[option.ID] = true
+
+		for j, action := range option.Actions {
+			if action.Path == "" {
+				return fmt.Errorf("cleaner %q: option %q: action #%d has no path", cleaner.ID, option.ID, j)
+			}
+
+			if err := validateCommand(action.Command); err != nil {
+				return fmt.Errorf("cleaner %q: option %q: action #%d: %w", cleaner.ID, option.ID, j, err)
+			}
+
+			if err := validateRegistryAction(action); err != nil {
+				return fmt.Errorf("cleaner %q: option %q: action #%d: %w", cleaner.ID, option.ID, j, err)
+			}
+
+			if err := validateFolderAction(action); err != nil {
+				return fmt.Errorf("cleaner %q: option %q: action #%d: %w", cleaner.ID, option.ID, j, err)
+			}
+
+			if err := validateSizeRules(action); err != nil {
+				return fmt.Errorf("cleaner %q: option %q: action #%d: %w", cleaner.ID, option.ID, j, err)
+			}
+
+			if err := validateShredAction(action); err != nil {
+				return fmt.Errorf("cleaner %q: option %q: action #%d: %w", cleaner.ID, option.ID, j, err)
+			}
+
+			for _, include := range action.Include {
+				if strings.Contains(include, detector.ProfilePlaceholder) {
+					return fmt.Errorf("cleaner %q: option %q: action #%d: include %q matches file names and can't use %s",
+						cleaner.ID, option.ID, j, include, detector.ProfilePlaceholder)
+				}
+			}
+
+			if !canDiscoverProfiles && strings.Contains(action.Path, detector.ProfilePlaceholder) {
+				return fmt.Errorf("cleaner %q: option %q: action #%d uses %s without profile discovery",
+					cleaner.ID, option.ID, j, detector.ProfilePlaceholder)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateCommand checks that the command is one cleaning knows, so a typo
+// can't end up deleting files
+func validateCommand(command string) error {
+	switch command {
+	case "delete", "truncate", "shred", "vacuum", models.RegistryDeleteKey, models.RegistryDeleteValue:
+		return nil
+	case "":
+		return errors.New("action has no command")
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// validateDetection checks a detection rule and its nested rules
+func validateDetection(detection models.Detection) error {
+	switch detection.Type {
+	case "", "path", "file", "dir", "always", "registry", "executable":
+	case "all", "any", "not":
+		if len(detection.Rules) == 0 {
+			return fmt.Errorf("%q needs at least one rule", detection.Type)
+		}
+		for _, rule := range detection.Rules {
+			if err := validateDetection(rule); err != nil {
+				return err
+			}
+		}
+	case "contains":
+		if detection.Pattern == "" {
+			return errors.New(`"contains" needs a pattern`)
+		}
+	case "ini_key", "json_key":
+		if detection.Key == "" {
+			return fmt.Errorf("%q needs a key", detection.Type)
+		}
+	case "version":
+		if detection.MinVersion == "" {
+			return errors.New(`"version" needs min_version`)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", detection.Type)
+	}
+
+	for _, check := range detection.Registry {
+		if _, _, ok := detector.SplitRegistryKey(check.Key); !ok {
+			return fmt.Errorf("unknown registry root in %q", check.Key)
+		}
+		if check.Pattern != "" {
+			if _, err := regexp.Compile(check.Pattern); err != nil {
+				return fmt.Errorf("registry %q: invalid pattern: %w", check.Key, err)
+			}
+		}
+	}
+
+	if detection.Pattern != "" {
+		if _, err := regexp.Compile(detection.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateFolderAction checks the folder settings, which only walks can use,
+// and the file name regex
+func validateFolderAction(action models.Action) error {
+	if action.Regex != "" {
+		if action.Command == models.RegistryDeleteKey || action.Command == models.RegistryDeleteValue {
+			return errors.New("regex is not used by registry commands")
+		}
+		if _, err := regexp.Compile(action.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	if action.Depth < 0 {
+		return fmt.Errorf("negative depth %d", action.Depth)
+	}
+	if action.Depth > 0 && action.Search != "walk.files" && action.Search != "walk.all" && action.Search != "walk.dirs" {
+		return errors.New("depth needs a walk.files, walk.all or walk.dirs search")
+	}
+	if action.DeleteEmptyDirs && action.Search != "walk.files" && action.Search != "walk.all" {
+		return errors.New("delete_empty_dirs needs a walk.files or walk.all search")
+	}
+	if action.RemoveRoot && !action.DeleteEmptyDirs && action.Search != "walk.all" {
+		return errors.New("remove_root needs walk.all or delete_empty_dirs")
+	}
+	return nil
+}
+
+// validateSizeRules checks the size limits and retention rules, which apply
+// to the files an action matches one by one and not to folders matched as a whole
+func validateSizeRules(action models.Action) error {
+	if action.MaxSize > 0 && action.MinSize > action.MaxSize {
+		return fmt.Errorf("min_size %d is above max_size %d", action.MinSize, action.MaxSize)
+	}
+	if action.KeepNewest < 0 {
+		return fmt.Errorf("negative keep_newest %d", action.KeepNewest)
+	}
+
+	sized := action.MinSize > 0 || action.MaxSize > 0
+	retained := action.KeepNewest > 0 || action.KeepTotalSize > 0
+	if !sized && !retained {
+		return nil
+	}
+	switch action.Search {
+	case "glob", "walk.files", "walk.all":
+	case "file":
+		if retained {
+			return errors.New("keep_newest and keep_total_size need a glob, walk.files or walk.all search")
+		}
+	case "":
+		// without a search, discovery globs paths with wildcards and stats the rest as single files
+		pattern := strings.ReplaceAll(action.Path, detector.ProfilePlaceholder, "")
+		if retained && !filesystem.HasMeta(pattern) {
+			return errors.New("keep_newest and keep_total_size need a glob, walk.files or walk.all search")
+		}
+	default:
+		return errors.New("size limits and retention need a file, glob, walk.files or walk.all search")
+	}
+	return nil
+}
+
+// validateShredAction checks the overwrite settings, which only "shred" uses
+func validateShredAction(action models.Action) error {
+	if action.Command != "shred" {
+		if action.Passes != 0 || action.Fill != "" {
+			return errors.New(`passes and fill are only used by "shred"`)
+		}
+		return nil
+	}
+
+	if action.Passes < 0 || action.Passes > models.MaxShredPasses {
+		return fmt.Errorf("passes %d is outside 1-%d", action.Passes, models.MaxShredPasses)
+	}
+	switch action.Fill {
+	case "", models.ShredFillRandom, models.ShredFillZero:
+	default:
+		return fmt.Errorf("unknown fill %q, want %q or %q", action.Fill, models.ShredFillRandom, models.ShredFillZero)
+	}
+	return nil
+}
+
+// validateRegistryAction checks the key and value pattern of registry commands.
+// Whole keys are only deleted two or more levels below the root, so a typo
+// can't take out HKCU\Software.
+func validateRegistryAction(action models.Action) error {
+	switch action.Command {
+	case models.RegistryDeleteKey, models.RegistryDeleteValue:
+	default:
+		if action.Value != "" {
+			return fmt.Errorf("value is only used by %s", models.RegistryDeleteValue)
+		}
+		return nil
+	}
+
+	_, subkey, ok := detector.SplitRegistryKey(action.Path)
+	if !ok {
+		return fmt.Errorf("unknown registry root in %q", action.Path)
+	}
+
+	if action.Command == models.RegistryDeleteKey {
+		if strings.Count(subkey, `\`) < 1 {
+			return fmt.Errorf("refusing to delete top-level registry key %q", action.Path)
+		}
+		return nil
+	}
+
+	if action.Value == "" {
+		return fmt.Errorf("%s needs a value", models.RegistryDeleteValue)
+	}
+	if _, err := path.Match(action.Value, ""); err != nil {
+		return fmt.Errorf("invalid value pattern %q: %w", action.Value, err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package cleaners
+
+import (
+	"backend/internal/models"
+	"testing"
+)
+
+func TestValidateSizeRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  models.Action
+		wantErr bool
+	}{
+		{"file with size limits", models.Action{Search: "file", Path: "/a.log", MaxSize: 1 << 20}, false},
+		{"glob with retention", models.Action{Search: "glob", Path: "/logs/*.log", KeepNewest: 3}, false},
+		{"walk with retention", models.Action{Search: "walk.files", Path: "/logs", KeepTotalSize: 1 << 20}, false},
+		{"no search with size limits", models.Action{Path: "/a.log", MinSize: 1}, false},
+		{"no search with retention on a glob", models.Action{Path: "/logs/*.log", KeepNewest: 3}, false},
+		{"no search with retention on a brace glob", models.Action{Path: "/logs/{a,b}.log", KeepNewest: 3}, false},
+		{"no rules on a folder search", models.Action{Search: "walk.dirs", Path: "/logs"}, false},
+		{"file with retention", models.Action{Search: "file", Path: "/a.log", KeepNewest: 3}, true},
+		{"no search with retention on a file", models.Action{Path: "/a.log", KeepNewest: 3}, true},
+		{"folder search with size limits", models.Action{Search: "walk.dirs", Path: "/logs", MaxSize: 1}, true},
+		{"min above max", models.Action{Search: "glob", Path: "/*.log", MinSize: 2, MaxSize: 1}, true},
+		{"negative keep_newest", models.Action{Search: "glob", Path: "/*.log", KeepNewest: -1}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.action.Command = "delete"
+			cleaner := models.Cleaner{
+				ID: "test", Name: "Test",
+				Detect:  models.Detection{Type: "always"},
+				Options: []models.Option{{ID: "logs", Actions: []models.Action{test.action}}},
+			}
+			if err := ValidateCleaner(cleaner); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		wantErr bool
+	}{
+		{"delete", false},
+		{"truncate", false},
+		{"shred", false},
+		{"vacuum", false},
+		{models.RegistryDeleteKey, false},
+		{models.RegistryDeleteValue, false},
+		{"", true},
+		{"delte", true},
+		{"sqlite.vacuum", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.command, func(t *testing.T) {
+			action := models.Action{Command: test.command, Search: "walk.files", Path: "/logs"}
+			switch test.command {
+			case models.RegistryDeleteKey:
+				action = models.Action{Command: test.command, Path: `HKCU\Software\App\Cache`}
+			case models.RegistryDeleteValue:
+				action = models.Action{Command: test.command, Path: `HKCU\Software\App`, Value: "MRU*"}
+			}
+			cleaner := models.Cleaner{
+				ID: "test", Name: "Test",
+				Detect:  models.Detection{Type: "always"},
+				Options: []models.Option{{ID: "logs", Actions: []models.Action{action}}},
+			}
+			if err := ValidateCleaner(cleaner); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateProfileInclude(t *testing.T) {
+	cleaner := models.Cleaner{
+		ID: "test", Name: "Test",
+		Detect:   models.Detection{Type: "always"},
+		Profiles: &models.ProfileDiscovery{Type: "glob", Root: "/profiles"},
+		Options: []models.Option{{ID: "cache", Actions: []models.Action{
+			{Command: "delete", Search: "walk.files", Path: "{{profile}}/cache", Include: []string{"*.tmp"}},
+		}}},
+	}
+	if err := ValidateCleaner(cleaner); err != nil {
+		t.Fatalf("name pattern rejected: %v", err)
+	}
+
+	cleaner.Options[0].Actions[0].Include = []string{"{{profile}}/cache/*.tmp"}
+	if err := ValidateCleaner(cleaner); err == nil {
+		t.Error("include with {{profile}} accepted")
+	}
+}
@@ -5,4 +5,16 @@ import "time"
 var (
 	GetCleanersContextTimeout   = 10 * time.Second
 	HandlePreviewContextTimeout = 30 * time.Second
+	HandleCleanContextTimeout   = 10 * time.Minute
+	WipeFreeSpaceContextTimeout = 12 * time.Hour
+
+	// DetectionCacheTTL is how long a cleaner's detection result is reused by GET /api/cleaners
+	DetectionCacheTTL = 5 * time.Minute
+
+	// RegistryBackupDir receives a .reg export of every registry key and value before it is deleted
+	RegistryBackupDir = "./backups"
+
+	// WipeFreeSpaceMargin is the least free space a wipe leaves, so the volume
+	// never fills up completely
+	WipeFreeSpaceMargin uint64 = 1 << 30
 )
@@ -6,25 +6,80 @@ package handlers
 import (
 	"backend/internal/cleaners"
 	"backend/internal/constants"
+	"backend/internal/i18n"
 	"backend/internal/models"
 	"backend/internal/service"
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io/fs"
 	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// requestLocale resolves the response locale from the ?lang= query parameter
+// and the Accept-Language header, falling back to English.
+func requestLocale(c *gin.Context) string {
+	return i18n.ResolveLocale(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
+
+// errorBody builds an error response carrying both the message ID and its localized text
+func errorBody(locale string, messageID string, args ...any) gin.H {
+	return gin.H{
+		"error":    i18n.Message(locale, messageID, args...),
+		"error_id": messageID,
+	}
+}
+
+// cancelledBody builds the response for an operation aborted by the user
+func cancelledBody(locale string, messageID string, data any) gin.H {
+	return gin.H{
+		"message":    i18n.Message(locale, messageID),
+		"message_id": messageID,
+		"partial":    true,
+		"data":       data,
+	}
+}
+
+// rejectUnknownIDs answers 400 with the unknown requests when ?strict=true is
+// given and some requests don't match any cleaner option. It reports whether
+// the request was rejected.
+func rejectUnknownIDs(c *gin.Context, locale string, requests []models.CleanRequest,
+	cleanerMap map[string]map[string][]models.Action) bool {
+	if c.Query("strict") != "true" {
+		return false
+	}
+
+	unknown := service.UnknownRequests(requests, cleanerMap)
+	if len(unknown) == 0 {
+		return false
+	}
+
+	body := errorBody(locale, i18n.MsgUnknownIDs)
+	body["unknown"] = unknown
+	c.JSON(http.StatusBadRequest, body)
+	return true
+}
+
 // GetCleaners handles the discovery of system cleaners.
 //
 // It loads all available cleaner definitions, checks which ones are actually
 // installed on the host system, and returns the filtered list as a JSON response.
+// Names, labels, descriptions and warnings are localized per ?lang= or Accept-Language.
+// Detection results are cached for a few minutes; ?refresh=true re-runs detection.
+// Each cleaner reports the matched rule and when it was evaluated in "detected".
+//
+// Only installed cleaners with options for this OS are listed unless
+// ?include=all is given, in which case every cleaner is returned with its
+// "installed" and "supported_on_os" flags, per-option "applicable" flags and
+// the reason a cleaner or option is unavailable.
 //
 // GET /api/cleaners
 func GetCleaners(c *gin.Context) {
+	locale := requestLocale(c)
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), constants.GetCleanersContextTimeout)
 	defer cancel()
 
@@ -35,52 +90,50 @@ func GetCleaners(c *gin.Context) {
 	allCleaners, err := cleaners.LoadAllCleaners(ctx)
 	if err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Review cancelled",
-				"partial": true,
-				"data": nil,
-			})
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgReviewCancelled, nil))
 			return
 		}
 
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			c.JSON(http.StatusRequestTimeout, gin.H{"error": "Request timed out"})
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
 			return
 		}
 
-		slog.Error("Error loading all cleaners: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error loading cleaners: %v", err)})
+		slog.Error("Error loading all cleaners", "error", err)
+		c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgLoadCleaners, err))
 		return
 	}
 
-	installedCleaners, err := cleaners.FilterOnlyInstalledCleaners(ctx, allCleaners)
+	listCleaners := cleaners.FilterOnlyInstalledCleaners
+	if c.Query("include") == "all" {
+		listCleaners = cleaners.DetectCleaners
+	}
+
+	installedCleaners, err := listCleaners(ctx, allCleaners, c.Query("refresh") == "true")
 	if err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Review cancelled",
-				"partial": true,
-				"data": installedCleaners,
-			})
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgReviewCancelled, i18n.LocalizeCleaners(installedCleaners, locale)))
 			return
 		}
 
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			c.JSON(http.StatusRequestTimeout, gin.H{"error": "Request timed out"})
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
 			return
 		}
 
-		slog.Error("Error filtering installed cleaners: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error filtering installed cleaners: %v", err)})
+		slog.Error("Error filtering installed cleaners", "error", err)
+		c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgFilterCleaners, err))
 		return
 	}
 
-	c.JSON(http.StatusOK, &installedCleaners)
+	localizedCleaners := i18n.LocalizeCleaners(installedCleaners, locale)
+	c.JSON(http.StatusOK, &localizedCleaners)
 
 	for _, cleaner := range installedCleaners {
-		slog.Info("Found cleaner %s\n", cleaner.Name)
-		slog.Info("Option ID: %s\n", cleaner.ID)
-		slog.Info("Option Name: %s\n", cleaner.Name)
-		slog.Info("Option Description: %s\n", cleaner.Description)
+		if !cleaner.Installed {
+			continue
+		}
+		slog.Info("Found cleaner", "id", cleaner.ID, "name", cleaner.Name, "description", cleaner.Description)
 	}
 }
 
@@ -89,16 +142,28 @@ func GetCleaners(c *gin.Context) {
 // It expects a JSON body containing a list of structures.CleanRequest.
 // It loads the cleaner configuration map, performs the analysis to determine
 // space to be freed or files to be removed, and returns a detailed JSON response.
+// Unknown IDs and paths that couldn't be inspected are listed in each item's
+// "errors"; with ?strict=true unknown IDs are rejected with 400 instead.
+// Both apparent and allocated sizes are reported; ?size=allocated makes
+// "total_size" the allocated total instead of the apparent one.
 //
 // POST /api/preview
 func HandlePreview(c *gin.Context) {
+	locale := requestLocale(c)
+
 	var requests []models.CleanRequest
 	if err := c.ShouldBindJSON(&requests); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidJSON))
+		return
+	}
+
+	sizeBasis := c.DefaultQuery("size", models.SizeApparent)
+	if !service.SetSizeBasis(&models.AnalyzeResponse{}, sizeBasis) {
+		c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidSizeBasis, sizeBasis))
 		return
 	}
 
-	log.Println("DEBUG: Cleaners - ", requests)
+	slog.Debug("Preview requested", "requests", requests)
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), constants.HandlePreviewContextTimeout)
 	defer cancel()
@@ -109,56 +174,214 @@ func HandlePreview(c *gin.Context) {
 
 	cleanerMap, err := service.LoadCleanerMap(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error loading cleaners: %v", err)})
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
+			return
+		}
+
+		if errors.Is(ctx.Err(), context.Canceled) {
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgReviewCancelled, nil))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgLoadCleaners, err))
+		return
+	}
+
+	if rejectUnknownIDs(c, locale, requests, cleanerMap) {
 		return
 	}
 
 	response, err := service.AnalyzeRequests(ctx, requests, cleanerMap)
 	if err != nil {
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			c.JSON(http.StatusRequestTimeout, gin.H{"error": "Request timed out"})
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
 			return
 		}
 
 		if errors.Is(ctx.Err(), context.Canceled) {
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Review cancelled",
-				"partial": true,
-				"data": response,
-			})
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgReviewCancelled, response))
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error processing requests: %v", err)})
+		c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgProcessRequests, err))
 		return
 	}
-	slog.Debug("DEBUG: AnalyzeResponse - ", *response)
+	service.SetSizeBasis(response, sizeBasis)
+	slog.Debug("Preview finished", "response", *response)
 
 	c.JSON(http.StatusOK, &response)
 }
 
 // HandleClean executes the cleanup process.
 //
-// It is intended to receive confirmation of items to delete and perform
-// the actual file removal operations.
+// It expects the same JSON body as HandlePreview and removes the files and
+// registry entries the preview reported for it. Registry entries are backed up
+// to a .reg file first, and nothing is removed when that fails. An aborted clean
+// returns what was removed so far. Unknown IDs are handled as in HandlePreview.
 //
 // POST /api/clean
 func HandleClean(c *gin.Context) {
-	// TODO: Implement the cleaning logic
+	locale := requestLocale(c)
+
+	var requests []models.CleanRequest
+	if err := c.ShouldBindJSON(&requests); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidJSON))
+		return
+	}
+
+	slog.Info("Clean requested", "requests", requests)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), constants.HandleCleanContextTimeout)
+	defer cancel()
+
+	abortManager := service.GetAbortManager()
+	abortManager.SetOperation(cancel)
+	defer abortManager.Clear()
+
+	cleanerMap, err := service.LoadCleanerMap(ctx)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
+			return
+		}
+
+		if errors.Is(ctx.Err(), context.Canceled) {
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgCleanCancelled, nil))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgLoadCleaners, err))
+		return
+	}
+
+	if rejectUnknownIDs(c, locale, requests, cleanerMap) {
+		return
+	}
+
+	response, err := service.CleanRequests(ctx, requests, cleanerMap)
+	if err != nil {
+		if errors.Is(err, service.ErrRegistryBackup) {
+			slog.Error("Clean refused", "error", err)
+			c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgRegistryBackup, err))
+			return
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
+			return
+		}
+
+		if errors.Is(ctx.Err(), context.Canceled) {
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgCleanCancelled, response))
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgProcessRequests, err))
+		return
+	}
+	slog.Info("Clean finished", "size", response.TotalSize, "files", response.TotalFiles, "failed", response.TotalFailed)
+
+	c.JSON(http.StatusOK, &response)
 }
 
+// HandleAbort cancels the running scan or clean and the running free space wipe.
+//
+// POST /api/abort
 func HandleAbort(c *gin.Context) {
-	abortManager := service.GetAbortManager()
+	locale := requestLocale(c)
 
-	if abortManager.Abort() {
+	aborted := service.GetAbortManager().Abort()
+	if service.GetWipeAbortManager().Abort() {
+		aborted = true
+	}
+
+	if aborted {
 		slog.Info("Operation aborted by user")
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Operation cancelled",
+			"message":    i18n.Message(locale, i18n.MsgOperationCancelled),
+			"message_id": i18n.MsgOperationCancelled,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "No operation to cancel",
+		"message":    i18n.Message(locale, i18n.MsgNoOperation),
+		"message_id": i18n.MsgNoOperation,
 	})
 }
+
+// HandleWipeFreeSpace overwrites the free space of the volume holding a folder.
+//
+// It expects a JSON models.WipeRequest and answers with a models.WipeResult
+// once the temporary files are written and deleted, which can take long on a
+// large volume; GET /api/wipe-free-space reports the progress meanwhile. The
+// wipe is aborted by POST /api/abort like a clean, returning what was written.
+// Only one wipe runs at a time, others get 409.
+//
+// POST /api/wipe-free-space
+func HandleWipeFreeSpace(c *gin.Context) {
+	locale := requestLocale(c)
+
+	var request models.WipeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidJSON))
+		return
+	}
+	if request.Path == "" {
+		c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidWipe, "no path"))
+		return
+	}
+	switch request.Fill {
+	case "", models.ShredFillRandom, models.ShredFillZero:
+	default:
+		c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidWipe, fmt.Sprintf("unknown fill %q", request.Fill)))
+		return
+	}
+	// claimed before taking over the wipe's abort slot, which would cancel the running wipe
+	wipe, err := service.StartWipe(request)
+	if err != nil {
+		c.JSON(http.StatusConflict, errorBody(locale, i18n.MsgWipeRunning))
+		return
+	}
+
+	slog.Info("Free space wipe requested", "path", request.Path, "fill", request.Fill)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), constants.WipeFreeSpaceContextTimeout)
+	defer cancel()
+
+	// the wipe has its own abort slot, so scans started meanwhile don't cancel it
+	abortManager := service.GetWipeAbortManager()
+	abortManager.SetOperation(cancel)
+	defer abortManager.Clear()
+
+	result, err := wipe(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWipeMargin):
+			body := errorBody(locale, i18n.MsgWipeMargin, result.Margin)
+			body["data"] = result
+			c.JSON(http.StatusConflict, body)
+		case errors.Is(err, fs.ErrNotExist), errors.Is(err, service.ErrNotDirectory), errors.Is(err, errors.ErrUnsupported):
+			c.JSON(http.StatusBadRequest, errorBody(locale, i18n.MsgInvalidWipe, err))
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			c.JSON(http.StatusRequestTimeout, errorBody(locale, i18n.MsgRequestTimeout))
+		case errors.Is(ctx.Err(), context.Canceled):
+			c.JSON(http.StatusOK, cancelledBody(locale, i18n.MsgWipeCancelled, result))
+		default:
+			slog.Error("Free space wipe failed", "path", request.Path, "error", err)
+			c.JSON(http.StatusInternalServerError, errorBody(locale, i18n.MsgWipeFailed, err))
+		}
+		return
+	}
+	slog.Info("Free space wipe finished", "path", result.Path, "written", result.Written, "files", result.Files)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetWipeProgress reports the running or last free space wipe as a models.WipeProgress.
+//
+// GET /api/wipe-free-space
+func GetWipeProgress(c *gin.Context) {
+	c.JSON(http.StatusOK, service.GetWipeProgress())
+}
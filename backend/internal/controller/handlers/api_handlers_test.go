@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"backend/internal/constants"
+	"backend/internal/filesystem"
+	"backend/internal/i18n"
+	"backend/internal/models"
+	"backend/internal/routes"
+	"backend/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dataDir holds the files the test cleaners target
+var dataDir = filepath.Join(string(filepath.Separator), "data")
+
+// testCleaners is written to the in-memory resources directory; "cache" is
+// always detected, "missing" never is
+var testCleaners = map[string]string{
+	"cache.json": `{
+		"id": "cache", "name": "Cache", "description": "Test cache",
+		"detect": {"type": "always"},
+		"options": [
+			{"id": "files", "label": "Files", "description": "", "actions": [
+				{"command": "delete", "search": "walk.files", "path": "` + filepath.ToSlash(filepath.Join(dataDir, "cache")) + `"}
+			]},
+			{"id": "elsewhere", "label": "Elsewhere", "description": "", "actions": [
+				{"command": "delete", "search": "file", "path": "x", "os": ["no-such-os"]}
+			]}
+		]
+	}`,
+	"missing.json": `{
+		"id": "missing", "name": "Missing", "description": "Not installed",
+		"detect": {"type": "dir", "paths": ["/definitely/not/installed/anywhere"]},
+		"options": [{"id": "files", "label": "Files", "description": "", "actions": [
+			{"command": "delete", "search": "file", "path": "x"}
+		]}]
+	}`,
+}
+
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	api := router.Group(routes.APIGroup)
+	api.GET(routes.GetCleaners, GetCleaners)
+	api.POST(routes.Preview, HandlePreview)
+	api.POST(routes.Clean, HandleClean)
+	api.POST(routes.Abort, HandleAbort)
+	api.POST(routes.WipeFreeSpace, HandleWipeFreeSpace)
+	api.GET(routes.WipeFreeSpace, GetWipeProgress)
+	return router
+}
+
+// useTestResources installs an in-memory file system with the test cleaners
+// and five one-byte files in the cache folder
+func useTestResources(t *testing.T) *filesystem.Memory {
+	t.Helper()
+
+	memory := filesystem.NewMemory()
+	for name, definition := range testCleaners {
+		memory.WriteFile(filepath.Join("resources", name), []byte(definition))
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		memory.WriteFile(filepath.Join(dataDir, "cache", name), []byte("x"))
+	}
+
+	previous := filesystem.Set(memory)
+	t.Cleanup(func() { filesystem.Set(previous) })
+	return memory
+}
+
+func serve(router *gin.Engine, method string, target string, body any) *httptest.ResponseRecorder {
+	var reader *bytes.Reader
+	switch body := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(body))
+	default:
+		data, _ := json.Marshal(body)
+		reader = bytes.NewReader(data)
+	}
+
+	request := httptest.NewRequest(method, target, reader)
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func decode[T any](t *testing.T, recorder *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var value T
+	if err := json.Unmarshal(recorder.Body.Bytes(), &value); err != nil {
+		t.Fatalf("decode %s: %v", recorder.Body.String(), err)
+	}
+	return value
+}
+
+func TestGetCleaners(t *testing.T) {
+	useTestResources(t)
+	router := newTestRouter()
+
+	tests := []struct {
+		name       string
+		query      string
+		wantIDs    []string
+		wantOption map[string]bool // option ID of the first cleaner -> applicable
+	}{
+		{"installed only", "?refresh=true", []string{"cache"}, map[string]bool{"files": true}},
+		{"include all", "?include=all&refresh=true", []string{"cache", "missing"}, map[string]bool{"files": true, "elsewhere": false}},
+		{"cached", "", []string{"cache"}, map[string]bool{"files": true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(router, http.MethodGet, routes.APIGroup+routes.GetCleaners+test.query, nil)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", recorder.Code, recorder.Body.String())
+			}
+
+			cleaners := decode[[]models.Cleaner](t, recorder)
+			if len(cleaners) != len(test.wantIDs) {
+				t.Fatalf("got %d cleaners, want %v", len(cleaners), test.wantIDs)
+			}
+			for i, cleaner := range cleaners {
+				if cleaner.ID != test.wantIDs[i] {
+					t.Errorf("cleaner %d is %q, want %q", i, cleaner.ID, test.wantIDs[i])
+				}
+				if cleaner.Detected == nil || cleaner.Detected.DetectedAt.IsZero() {
+					t.Errorf("%s: detection result missing", cleaner.ID)
+				}
+				if cleaner.ID == "missing" && (cleaner.Installed || !cleaner.SupportedOnOS || cleaner.ReasonID != i18n.MsgReasonNotDetected || cleaner.Reason == "") {
+					t.Errorf("missing: installed %v, supported_on_os %v, reason %q (%s)", cleaner.Installed, cleaner.SupportedOnOS, cleaner.Reason, cleaner.ReasonID)
+				}
+				if cleaner.ID == "cache" && (!cleaner.Installed || !cleaner.SupportedOnOS || cleaner.Reason != "") {
+					t.Errorf("cache: installed %v, supported_on_os %v, reason %q", cleaner.Installed, cleaner.SupportedOnOS, cleaner.Reason)
+				}
+			}
+
+			options := make(map[string]bool)
+			for _, option := range cleaners[0].Options {
+				options[option.ID] = option.Applicable
+				if !option.Applicable && (option.ReasonID != i18n.MsgReasonNoActions || option.Reason == "") {
+					t.Errorf("option %q: reason %q (%s)", option.ID, option.Reason, option.ReasonID)
+				}
+			}
+			if len(options) != len(test.wantOption) {
+				t.Fatalf("got options %v, want %v", options, test.wantOption)
+			}
+			for id, applicable := range test.wantOption {
+				if options[id] != applicable {
+					t.Errorf("option %q applicable = %v, want %v", id, options[id], applicable)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePreview(t *testing.T) {
+	useTestResources(t)
+	router := newTestRouter()
+
+	tests := []struct {
+		name       string
+		body       any
+		wantStatus int
+		wantFiles  uint64
+		wantItems  int
+	}{
+		{"known option", []models.CleanRequest{{CleanerID: "cache", OptionID: "files"}}, http.StatusOK, 5, 1},
+		{"unknown ids", []models.CleanRequest{{CleanerID: "nope", OptionID: "files"}, {CleanerID: "cache", OptionID: "nope"}}, http.StatusOK, 0, 2},
+		{"invalid json", "{", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(router, http.MethodPost, routes.APIGroup+routes.Preview, test.body)
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.wantStatus, recorder.Body.String())
+			}
+			if test.wantStatus != http.StatusOK {
+				if body := decode[map[string]any](t, recorder); body["error_id"] != i18n.MsgInvalidJSON {
+					t.Errorf("got %v, want error_id %s", body, i18n.MsgInvalidJSON)
+				}
+				return
+			}
+
+			response := decode[models.AnalyzeResponse](t, recorder)
+			if response.TotalFiles != test.wantFiles || len(response.Items) != test.wantItems {
+				t.Errorf("got %d files in %d items, want %d in %d", response.TotalFiles, len(response.Items), test.wantFiles, test.wantItems)
+			}
+		})
+	}
+}
+
+func TestHandlePreviewSizeBasis(t *testing.T) {
+	useTestResources(t)
+	router := newTestRouter()
+	requests := []models.CleanRequest{{CleanerID: "cache", OptionID: "files"}}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantBasis  string
+	}{
+		{"", http.StatusOK, models.SizeApparent},
+		{"?size=allocated", http.StatusOK, models.SizeAllocated},
+		{"?size=bogus", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		recorder := serve(router, http.MethodPost, routes.APIGroup+routes.Preview+test.query, requests)
+		if recorder.Code != test.wantStatus {
+			t.Fatalf("%q: status %d, want %d: %s", test.query, recorder.Code, test.wantStatus, recorder.Body.String())
+		}
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+
+		response := decode[models.AnalyzeResponse](t, recorder)
+		want := response.TotalApparentSize
+		if test.wantBasis == models.SizeAllocated {
+			want = response.TotalAllocatedSize
+		}
+		if response.SizeBasis != test.wantBasis || response.TotalSize != want || response.TotalAllocatedSize <= response.TotalApparentSize {
+			t.Errorf("%q: got %+v", test.query, response)
+		}
+	}
+}
+
+func TestHandlePreviewTimeout(t *testing.T) {
+	memory := useTestResources(t)
+	filesystem.Set(slowFS{Memory: memory, delay: 50 * time.Millisecond})
+
+	previous := constants.HandlePreviewContextTimeout
+	constants.HandlePreviewContextTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { constants.HandlePreviewContextTimeout = previous })
+
+	recorder := serve(newTestRouter(), http.MethodPost, routes.APIGroup+routes.Preview,
+		[]models.CleanRequest{{CleanerID: "cache", OptionID: "files"}})
+
+	if recorder.Code != http.StatusRequestTimeout {
+		t.Fatalf("status %d, want %d: %s", recorder.Code, http.StatusRequestTimeout, recorder.Body.String())
+	}
+}
+
+func TestHandlePreviewAborted(t *testing.T) {
+	memory := useTestResources(t)
+	filesystem.Set(slowFS{Memory: memory, delay: 50 * time.Millisecond})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- serve(newTestRouter(), http.MethodPost, routes.APIGroup+routes.Preview,
+			[]models.CleanRequest{{CleanerID: "cache", OptionID: "files"}})
+	}()
+
+	abortManager := service.GetAbortManager()
+	for !abortManager.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+	abortManager.Abort()
+
+	recorder := <-done
+	body := decode[map[string]any](t, recorder)
+	if recorder.Code != http.StatusOK || body["message_id"] != i18n.MsgReviewCancelled || body["partial"] != true {
+		t.Errorf("status %d, body %v", recorder.Code, body)
+	}
+}
+
+func TestHandleClean(t *testing.T) {
+	memory := useTestResources(t)
+	router := newTestRouter()
+
+	recorder := serve(router, http.MethodPost, routes.APIGroup+routes.Clean,
+		[]models.CleanRequest{{CleanerID: "cache", OptionID: "files"}, {CleanerID: "nope", OptionID: "files"}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	response := decode[models.CleanResponse](t, recorder)
+	if response.TotalFiles != 5 || response.TotalSize != 5 || len(response.Items) != 2 {
+		t.Errorf("got %+v", response)
+	}
+	if entries, _ := memory.ReadDir(filepath.Join(dataDir, "cache")); len(entries) != 0 {
+		t.Errorf("%d files left after cleaning", len(entries))
+	}
+
+	if recorder := serve(router, http.MethodPost, routes.APIGroup+routes.Clean, "not json"); recorder.Code != http.StatusBadRequest {
+		t.Errorf("invalid json: status %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+}
+
+func TestStrictUnknownIDs(t *testing.T) {
+	memory := useTestResources(t)
+	router := newTestRouter()
+	requests := []models.CleanRequest{{CleanerID: "cache", OptionID: "files"}, {CleanerID: "cache", OptionID: "nope"}}
+
+	for _, route := range []string{routes.Preview, routes.Clean} {
+		recorder := serve(router, http.MethodPost, routes.APIGroup+route+"?strict=true", requests)
+		if recorder.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d, want %d: %s", route, recorder.Code, http.StatusBadRequest, recorder.Body.String())
+		}
+
+		body := decode[map[string]any](t, recorder)
+		if unknown, _ := body["unknown"].([]any); body["error_id"] != i18n.MsgUnknownIDs || len(unknown) != 1 {
+			t.Errorf("%s: got %v, want error_id %s with one unknown request", route, body, i18n.MsgUnknownIDs)
+		}
+	}
+
+	if entries, _ := memory.ReadDir(filepath.Join(dataDir, "cache")); len(entries) != 5 {
+		t.Errorf("%d files left after a rejected clean, want 5", len(entries))
+	}
+}
+
+func TestHandleAbort(t *testing.T) {
+	router := newTestRouter()
+	abortManager := service.GetAbortManager()
+
+	tests := []struct {
+		name      string
+		running   bool
+		wantMsgID string
+	}{
+		{"nothing running", false, i18n.MsgNoOperation},
+		{"operation running", true, i18n.MsgOperationCancelled},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.running {
+				abortManager.SetOperation(cancel)
+			}
+			defer abortManager.Clear()
+
+			recorder := serve(router, http.MethodPost, routes.APIGroup+routes.Abort, nil)
+			body := decode[map[string]any](t, recorder)
+
+			if recorder.Code != http.StatusOK || body["message_id"] != test.wantMsgID {
+				t.Errorf("status %d, body %v; want message_id %s", recorder.Code, body, test.wantMsgID)
+			}
+			if test.running && ctx.Err() == nil {
+				t.Error("running operation not cancelled")
+			}
+		})
+	}
+}
+
+// slowFS delays every directory listing
+type slowFS struct {
+	*filesystem.Memory
+	delay time.Duration
+}
+
+func (s slowFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	time.Sleep(s.delay)
+	return s.Memory.ReadDir(name)
+}
+
+func TestHandleWipeFreeSpace(t *testing.T) {
+	memory := useTestResources(t)
+	memory.SetCapacity(8 << 20)
+	previous := constants.WipeFreeSpaceMargin
+	constants.WipeFreeSpaceMargin = 1 << 20
+	t.Cleanup(func() { constants.WipeFreeSpaceMargin = previous })
+	router := newTestRouter()
+	target := routes.APIGroup + routes.WipeFreeSpace
+
+	tests := []struct {
+		name       string
+		body       any
+		wantStatus int
+		wantError  string
+	}{
+		{"wiped", models.WipeRequest{Path: dataDir}, http.StatusOK, ""},
+		{"no path", models.WipeRequest{}, http.StatusBadRequest, i18n.MsgInvalidWipe},
+		{"unknown fill", models.WipeRequest{Path: dataDir, Fill: "ones"}, http.StatusBadRequest, i18n.MsgInvalidWipe},
+		{"missing folder", models.WipeRequest{Path: filepath.Join(dataDir, "missing")}, http.StatusBadRequest, i18n.MsgInvalidWipe},
+		{"within margin", models.WipeRequest{Path: dataDir, Margin: 16 << 20}, http.StatusConflict, i18n.MsgWipeMargin},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(router, http.MethodPost, target, test.body)
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.wantStatus, recorder.Body.String())
+			}
+			if test.wantError != "" {
+				if body := decode[map[string]any](t, recorder); body["error_id"] != test.wantError {
+					t.Errorf("error %v, want %s", body["error_id"], test.wantError)
+				}
+				return
+			}
+
+			result := decode[models.WipeResult](t, recorder)
+			if result.Written == 0 || result.Written != result.Target || result.Target != result.FreeBefore-result.Margin {
+				t.Errorf("got %+v", result)
+			}
+			progress := decode[models.WipeProgress](t, serve(router, http.MethodGet, target, nil))
+			if progress.Running || progress.Written != result.Written {
+				t.Errorf("progress: %+v", progress)
+			}
+		})
+	}
+}
+
+// slowWriteFS delays every write to a created file and discards the data, so
+// a wipe of its huge capacity runs until it is aborted
+type slowWriteFS struct {
+	*filesystem.Memory
+	delay time.Duration
+}
+
+func (s slowWriteFS) CreateTemp(dir string, pattern string) (filesystem.WritableFile, error) {
+	file, err := s.Memory.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return slowFile{WritableFile: file, delay: s.delay}, nil
+}
+
+type slowFile struct {
+	filesystem.WritableFile
+	delay time.Duration
+}
+
+func (f slowFile) Write(p []byte) (int, error) {
+	time.Sleep(f.delay)
+	return len(p), nil
+}
+
+func TestWipeSurvivesOtherRequests(t *testing.T) {
+	memory := useTestResources(t)
+	memory.SetCapacity(1 << 40)
+	filesystem.Set(slowWriteFS{Memory: memory, delay: time.Millisecond})
+	previous := constants.WipeFreeSpaceMargin
+	constants.WipeFreeSpaceMargin = 1 << 20
+	t.Cleanup(func() { constants.WipeFreeSpaceMargin = previous })
+	router := newTestRouter()
+	target := routes.APIGroup + routes.WipeFreeSpace
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		done <- serve(router, http.MethodPost, target, models.WipeRequest{Path: dataDir, Fill: models.ShredFillZero})
+	}()
+
+	running := func() bool {
+		return decode[models.WipeProgress](t, serve(router, http.MethodGet, target, nil)).Running
+	}
+	for deadline := time.Now().Add(5 * time.Second); !running(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("wipe didn't start")
+		}
+	}
+
+	if recorder := serve(router, http.MethodGet, routes.APIGroup+routes.GetCleaners, nil); recorder.Code != http.StatusOK {
+		t.Fatalf("cleaners: status %d: %s", recorder.Code, recorder.Body.String())
+	}
+	preview := []models.CleanRequest{{CleanerID: "cache", OptionID: "files"}}
+	if recorder := serve(router, http.MethodPost, routes.APIGroup+routes.Preview, preview); recorder.Code != http.StatusOK {
+		t.Fatalf("preview: status %d: %s", recorder.Code, recorder.Body.String())
+	}
+	second := serve(router, http.MethodPost, target, models.WipeRequest{Path: dataDir})
+	if body := decode[map[string]any](t, second); second.Code != http.StatusConflict || body["error_id"] != i18n.MsgWipeRunning {
+		t.Errorf("second wipe: status %d, body %v", second.Code, body)
+	}
+
+	select {
+	case recorder := <-done:
+		t.Fatalf("wipe ended by other requests: status %d: %s", recorder.Code, recorder.Body.String())
+	default:
+	}
+	if !running() {
+		t.Fatal("wipe no longer running")
+	}
+
+	abort := decode[map[string]any](t, serve(router, http.MethodPost, routes.APIGroup+routes.Abort, nil))
+	if abort["message_id"] != i18n.MsgOperationCancelled {
+		t.Errorf("abort: %v", abort)
+	}
+
+	select {
+	case recorder := <-done:
+		body := decode[map[string]any](t, recorder)
+		if recorder.Code != http.StatusOK || body["message_id"] != i18n.MsgWipeCancelled {
+			t.Errorf("wipe: status %d, body %v", recorder.Code, body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("wipe not aborted")
+	}
+}
@@ -1,106 +1,144 @@
 package detector
 
 import (
+    "backend/internal/filesystem"
+    "backend/internal/i18n"
     "backend/internal/models"
     "os"
     "path/filepath"
+    "regexp"
     "runtime"
     "strings"
-
-    "golang.org/x/sys/windows/registry" // for Windows
 )
 
 
-// DetectInstalled перевіряє чи програма встановлена
+// DetectInstalled перевіряє чи програма встановлена (див. Detect для пояснення результату)
 func DetectInstalled(detection models.Detection) bool {
-    if detection.Type == "always" || (len(detection.Paths) == 0 && len(detection.Registry) == 0) {
-        return true
-    }
-
-    // 1. Перевірка шляхів
-    for _, path := range detection.Paths {
-        if CheckPathExists(path) {
-            return true
-        }
-    }
-
-    // 2. Перевірка реєстру (тільки Windows)
-    if runtime.GOOS == "windows" {
-        for _, reg := range detection.Registry {
-            if IsOSSupported(reg.OS) && checkRegistry(reg.Key) {
-                return true
-            }
-        }
-    }
-
-    return false
+    return Detect(detection).Installed
 }
 
+// ExpandPath розгортає змінні середовища ($VAR, а на Windows ще й %Var%)
 func ExpandPath(path string) string {
     expandedPath := os.ExpandEnv(path)
 
     if runtime.GOOS == "windows" {
-        replacer := strings.NewReplacer(
-            "%AppData%", os.Getenv("AppData"),
-            "%LocalAppData%", os.Getenv("LocalAppData"),
-            "%ProgramFiles%", os.Getenv("ProgramFiles"),
-            "%ProgramFiles(x86)%", os.Getenv("ProgramFiles(x86)"),
-            "%UserProfile%", os.Getenv("UserProfile"),
-            "%SystemRoot%", os.Getenv("SystemRoot"),
-            "%TEMP%", os.Getenv("TEMP"),
-            )
-        expandedPath = replacer.Replace(expandedPath)
+        expandedPath = ExpandWindowsVariables(expandedPath, os.Getenv)
     }
 
     return expandedPath
 }
 
+// windowsVariables - змінні, яких немає в оточенні під тим же ім'ям, зокрема
+// ті, що використовують визначення winapp2.ini; ключі в нижньому регістрі
+var windowsVariables = map[string]func(getenv func(string) string) string{
+    "commonappdata": func(getenv func(string) string) string { return getenv("ProgramData") },
+    "locallowappdata": func(getenv func(string) string) string {
+        if profile := getenv("UserProfile"); profile != "" {
+            return filepath.Join(profile, "AppData", "LocalLow")
+        }
+        return ""
+    },
+}
+
+// windowsVariableNames - звичні імена змінних оточення Windows у нижньому регістрі,
+// щоб %Temp% і %TEMP% знаходили ту саму змінну незалежно від getenv
+var windowsVariableNames = map[string]string{
+    "appdata":           "AppData",
+    "localappdata":      "LocalAppData",
+    "programfiles":      "ProgramFiles",
+    "programfiles(x86)": "ProgramFiles(x86)",
+    "programdata":       "ProgramData",
+    "userprofile":       "UserProfile",
+    "systemroot":        "SystemRoot",
+    "systemdrive":       "SystemDrive",
+    "windir":            "WinDir",
+    "temp":              "TEMP",
+    "tmp":               "TMP",
+    "public":            "Public",
+    "homedrive":         "HomeDrive",
+}
+
+var windowsVariable = regexp.MustCompile(`%([^%\\/]+)%`)
+
+// ExpandWindowsVariables замінює %Var% на значення змінної оточення без
+// урахування регістру, як це робить Windows. Невідомі та порожні змінні
+// лишаються як є, щоб шлях не перетворився на корінь диска.
+func ExpandWindowsVariables(path string, getenv func(string) string) string {
+    return windowsVariable.ReplaceAllStringFunc(path, func(token string) string {
+        name := strings.ToLower(token[1 : len(token)-1])
+
+        var value string
+        if resolve, ok := windowsVariables[name]; ok {
+            value = resolve(getenv)
+        } else if canonical, ok := windowsVariableNames[name]; ok {
+            value = getenv(canonical)
+        } else {
+            value = getenv(token[1 : len(token)-1])
+        }
+
+        if value == "" {
+            return token
+        }
+        return value
+    })
+}
+
 // CheckPathExists перевіряє чи існує шлях
 func CheckPathExists(path string) bool {
     // Розширити змінні оточення
     expanded := ExpandPath(path)
 
-    // Glob для wildcards
-    if strings.Contains(expanded, "*") {
-        matches, _ := filepath.Glob(expanded)
-        return len(matches) > 0
+    fsys := filesystem.Current()
+
+    // Glob для wildcards, зокрема ** та {a,b}; зупиняється на першому збігу
+    if filesystem.HasMeta(expanded) {
+        return filesystem.GlobExists(fsys, expanded)
     }
 
     // Простий stat
-    _, err := os.Stat(expanded)
-    return err == nil
+    return filesystem.Exists(fsys, expanded)
 }
 
-// checkRegistry перевіряє Windows реєстр
-func checkRegistry(keyPath string) bool {
-    if runtime.GOOS != "windows" {
-        return false
+// checkRegistry перевіряє ключ реєстру, а якщо вказано value чи pattern - ще й значення
+func checkRegistry(check models.RegistryCheck) (models.Message, bool) {
+    reader := Registry()
+
+    if check.Value == "" && check.Pattern == "" {
+        if !reader.KeyExists(check.Key) {
+            return models.Message{}, false
+        }
+        return models.Message{ID: i18n.MsgRuleRegistryKey, Args: []any{check.Key}}, true
     }
 
-    // Розібрати ключ: HKLM\SOFTWARE\...
-    parts := strings.SplitN(keyPath, "\\", 2)
-    if len(parts) != 2 {
-        return false
+    value, ok := reader.ReadValue(check.Key, check.Value)
+    if !ok {
+        return models.Message{}, false
     }
 
-    var rootKey registry.Key
-    switch parts[0] {
-    case "HKLM":
-        rootKey = registry.LOCAL_MACHINE
-    case "HKCU":
-        rootKey = registry.CURRENT_USER
-    default:
-        return false
+    name := check.Key + `\` + check.Value
+    if check.Pattern == "" {
+        return models.Message{ID: i18n.MsgRuleRegistryValue, Args: []any{name}}, true
     }
 
-    // Відкрити ключ
-    k, err := registry.OpenKey(rootKey, parts[1], registry.QUERY_VALUE)
-    if err != nil {
-        return false
+    if !valueMatches(value.Text(), check.Pattern) {
+        return models.Message{}, false
+    }
+    return models.Message{ID: i18n.MsgRuleRegistryPattern, Args: []any{name, check.Pattern}}, true
+}
+
+// IsArchSupported Is CPU architecture supported for this operation
+func IsArchSupported(archList []string) bool {
+    if len(archList) == 0 {
+        return true
     }
-    defer k.Close()
 
-    return true
+    currentArch := runtime.GOARCH
+    for _, arch := range archList {
+        if strings.EqualFold(arch, currentArch) {
+            return true
+        }
+    }
+    return false
 }
 
 // IsOSSupported Is OS supported for this operation
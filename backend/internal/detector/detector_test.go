@@ -0,0 +1,159 @@
+package detector
+
+import (
+	"backend/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPathExists(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("app", "beta", "config.json"), []byte("{}"))
+	memory.MkdirAll(testPath("{GUID}"))
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{testPath("app", "beta", "config.json"), true},
+		{testPath("app", "stable"), false},
+		{testPath("app", "*", "config.json"), true},
+		{testPath("app", "{stable,beta}"), true},
+		{testPath("app", "{stable,nightly}"), false},
+		{testPath("app", "bet?"), true},
+		{testPath("app", "[ab]eta", "config.json"), true},
+		{testPath("**", "config.json"), true},
+		{testPath("{GUID}"), true},
+	}
+
+	for _, test := range tests {
+		if got := CheckPathExists(test.path); got != test.want {
+			t.Errorf("CheckPathExists(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestDiscoverProfiles(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.MkdirAll(testPath("chrome", "Default"))
+	memory.MkdirAll(testPath("chrome", "Profile 2"))
+	memory.MkdirAll(testPath("chrome", "Crashpad"))
+	memory.WriteFile(testPath("firefox", "profiles.ini"), []byte("[Profile0]\nName=work\nIsRelative=1\nPath=Profiles/abc.work\n"))
+	memory.MkdirAll(testPath("firefox", "Profiles", "abc.work"))
+	memory.MkdirAll(testPath("firefox", "Profiles", "orphan"))
+	memory.WriteFile(testPath("firefox2", "profiles.ini"), []byte("[Profile0]\nPath=Profiles/work\n[Profile1]\nPath=Other/work\n"))
+	memory.MkdirAll(testPath("firefox2", "Profiles", "work"))
+	memory.MkdirAll(testPath("firefox2", "Other", "work"))
+	memory.WriteFile(testPath("brave", "Local State"), []byte(`{"profile":{"info_cache":{
+		"Default": {"name": "Me"}, "..": {}, "../chrome": {}, "Profile 1\\..\\..": {}, ".": {}}}}`))
+	memory.MkdirAll(testPath("brave", "Default"))
+	memory.MkdirAll(testPath("app", "p1"))
+	memory.MkdirAll(testPath("app", "p2"))
+	memory.WriteFile(testPath("app", "p3"), nil)
+
+	tests := []struct {
+		name      string
+		discovery models.ProfileDiscovery
+		wantIDs   []string
+	}{
+		{"chromium without Local State", models.ProfileDiscovery{Type: "chromium", Root: testPath("chrome")}, []string{"Default", "Profile 2"}},
+		{"firefox profiles.ini", models.ProfileDiscovery{Type: "firefox", Root: testPath("firefox")}, []string{"Profiles/abc.work"}},
+		{"firefox folders with the same name", models.ProfileDiscovery{Type: "firefox", Root: testPath("firefox2")}, []string{"Other/work", "Profiles/work"}},
+		{"chromium skips IDs leaving the root", models.ProfileDiscovery{Type: "chromium", Root: testPath("brave")}, []string{"Default"}},
+		{"glob skips files", models.ProfileDiscovery{Type: "glob", Root: testPath("app")}, []string{"p1", "p2"}},
+		{"glob pattern", models.ProfileDiscovery{Type: "glob", Root: testPath("app"), Pattern: "p{2,3}"}, []string{"p2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profiles := DiscoverProfiles(test.discovery)
+			var ids []string
+			for _, profile := range profiles {
+				ids = append(ids, profile.ID)
+			}
+			if len(ids) != len(test.wantIDs) {
+				t.Fatalf("got %v, want %v", ids, test.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != test.wantIDs[i] {
+					t.Fatalf("got %v, want %v", ids, test.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestFindRunning(t *testing.T) {
+	previous := SetProcesses(ProcessList{"systemd", "Firefox.exe", "/usr/bin/code"})
+	t.Cleanup(func() { SetProcesses(previous) })
+
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"firefox"}, "firefox"},
+		{[]string{"chrome", "firefox.exe"}, "firefox.exe"},
+		{[]string{"code"}, "code"},
+		{[]string{"chrome"}, ""},
+		{nil, ""},
+	}
+
+	for _, test := range tests {
+		name, running, err := FindRunning(test.names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != test.want || running != (test.want != "") {
+			t.Errorf("FindRunning(%v) = %q, %v, want %q", test.names, name, running, test.want)
+		}
+	}
+}
+
+func TestSystemProcessesListsItself(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	names, err := systemProcesses{}.RunningProcesses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if processKey(name) == processKey(executable) {
+			return
+		}
+	}
+	t.Errorf("%s not among %d running processes", filepath.Base(executable), len(names))
+}
+
+func TestExpandWindowsVariables(t *testing.T) {
+	env := map[string]string{
+		"TEMP":        `C:\Temp`,
+		"ProgramData": `C:\ProgramData`,
+		"CustomDir":   `D:\Custom`,
+	}
+	getenv := func(name string) string { return env[name] }
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`%TEMP%\a`, `C:\Temp\a`},
+		{`%Temp%\a`, `C:\Temp\a`},
+		{`%temp%\a`, `C:\Temp\a`},
+		{`%CommonAppData%\App`, `C:\ProgramData\App`},
+		{`%programdata%\App`, `C:\ProgramData\App`},
+		{`%CustomDir%\x`, `D:\Custom\x`},
+		{`%AppData%\App`, `%AppData%\App`},
+		{`%Documents%\App`, `%Documents%\App`},
+		{`C:\100%\x`, `C:\100%\x`},
+	}
+
+	for _, test := range tests {
+		if got := ExpandWindowsVariables(test.path, getenv); got != test.want {
+			t.Errorf("ExpandWindowsVariables(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package detector
+
+import (
+	"backend/internal/filesystem"
+	"path/filepath"
+	"testing"
+)
+
+// root is the top of the synthetic trees, an absolute path on every OS
+var root = filepath.Join(string(filepath.Separator), "data")
+
+// useMemoryFS swaps in an empty in-memory file system for the test
+func useMemoryFS(tb testing.TB) *filesystem.Memory {
+	tb.Helper()
+
+	memory := filesystem.NewMemory()
+	previous := filesystem.Set(memory)
+	tb.Cleanup(func() { filesystem.Set(previous) })
+	return memory
+}
+
+// testPath joins elements under root
+func testPath(elements ...string) string {
+	return filepath.Join(append([]string{root}, elements...)...)
+}
+
+// useMemoryRegistry swaps in an empty in-memory registry for the test
+func useMemoryRegistry(tb testing.TB) *MemoryRegistry {
+	tb.Helper()
+
+	registry := NewMemoryRegistry()
+	previous := SetRegistry(registry)
+	tb.Cleanup(func() { SetRegistry(previous) })
+	return registry
+}
@@ -0,0 +1,73 @@
+package detector
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ProcessLister lists the programs running on this machine
+type ProcessLister interface {
+	// RunningProcesses returns the executable names of running processes,
+	// e.g. "firefox" or "chrome.exe"
+	RunningProcesses() ([]string, error)
+}
+
+// ProcessList is a fixed list of running executables, for tests
+type ProcessList []string
+
+func (list ProcessList) RunningProcesses() ([]string, error) { return list, nil }
+
+var (
+	processMutex    sync.RWMutex
+	activeProcesses ProcessLister = systemProcesses{}
+)
+
+// SetProcesses replaces the process list consulted before cleaning and
+// returns the previous one
+func SetProcesses(lister ProcessLister) ProcessLister {
+	processMutex.Lock()
+	defer processMutex.Unlock()
+
+	previous := activeProcesses
+	activeProcesses = lister
+	return previous
+}
+
+// Processes returns the process list consulted before cleaning
+func Processes() ProcessLister {
+	processMutex.RLock()
+	defer processMutex.RUnlock()
+	return activeProcesses
+}
+
+// FindRunning returns the first of names that is running. Names match
+// case-insensitively and with or without an ".exe" suffix, so "firefox"
+// also finds firefox.exe on Windows.
+func FindRunning(names []string) (string, bool, error) {
+	if len(names) == 0 {
+		return "", false, nil
+	}
+
+	running, err := Processes().RunningProcesses()
+	if err != nil {
+		return "", false, err
+	}
+
+	seen := make(map[string]bool, len(running))
+	for _, process := range running {
+		seen[processKey(process)] = true
+	}
+	for _, name := range names {
+		if seen[processKey(name)] {
+			return name, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// processKey normalizes an executable name for comparison
+func processKey(name string) string {
+	name = strings.ToLower(filepath.Base(strings.TrimSpace(name)))
+	return strings.TrimSuffix(name, ".exe")
+}
@@ -0,0 +1,43 @@
+package detector
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// systemProcesses lists the processes of this machine from /proc
+type systemProcesses struct{}
+
+// RunningProcesses names each process after its executable. Processes of
+// other users whose exe link can't be read fall back to the command line,
+// then to comm, which the kernel truncates to 15 characters.
+func (systemProcesses) RunningProcesses() ([]string, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+
+		if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+			names = append(names, filepath.Base(exe))
+			continue
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil && len(cmdline) > 0 {
+			argv0, _, _ := bytes.Cut(cmdline, []byte{0})
+			names = append(names, filepath.Base(string(argv0)))
+			continue
+		}
+		if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+			names = append(names, string(bytes.TrimSpace(comm)))
+		}
+	}
+	return names, nil
+}
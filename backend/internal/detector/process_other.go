@@ -0,0 +1,27 @@
+//go:build !windows && !linux
+
+package detector
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// systemProcesses lists the processes of this machine with ps
+type systemProcesses struct{}
+
+func (systemProcesses) RunningProcesses() ([]string, error) {
+	output, err := exec.Command("ps", "-A", "-o", "comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, filepath.Base(line))
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,150 @@
+package detector
+
+import (
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"bufio"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ProfilePlaceholder is replaced with each discovered profile folder when
+// actions are expanded per profile
+const ProfilePlaceholder = "{{profile}}"
+
+// chromiumDefaultProfiles are the folder patterns used when Local State can't be read
+var chromiumDefaultProfiles = []string{"Default", "Profile *"}
+
+// DiscoverProfiles finds the profiles described by discovery, sorted by ID.
+// Only profiles whose folder exists are returned.
+func DiscoverProfiles(discovery models.ProfileDiscovery) []models.Profile {
+	root := ExpandPath(discovery.Root)
+
+	var profiles []models.Profile
+	switch discovery.Type {
+	case "chromium":
+		profiles = discoverChromiumProfiles(root)
+	case "firefox":
+		profiles = discoverFirefoxProfiles(root)
+	default:
+		pattern := discovery.Pattern
+		if pattern == "" {
+			pattern = "*"
+		}
+		profiles = globProfiles(root, []string{pattern})
+	}
+
+	existing := profiles[:0]
+	for _, profile := range profiles {
+		if info, err := filesystem.Current().Stat(profile.Path); err == nil && info.IsDir() {
+			existing = append(existing, profile)
+		}
+	}
+
+	sort.Slice(existing, func(i, j int) bool { return existing[i].ID < existing[j].ID })
+	return existing
+}
+
+// discoverChromiumProfiles reads profile.info_cache from the "Local State" file
+// in the User Data folder, falling back to the usual folder names.
+func discoverChromiumProfiles(root string) []models.Profile {
+	data, err := filesystem.Current().ReadFile(filepath.Join(root, "Local State"))
+	if err != nil {
+		return globProfiles(root, chromiumDefaultProfiles)
+	}
+
+	var localState struct {
+		Profile struct {
+			InfoCache map[string]struct {
+				Name string `json:"name"`
+			} `json:"info_cache"`
+		} `json:"profile"`
+	}
+	if err := json.Unmarshal(data, &localState); err != nil || len(localState.Profile.InfoCache) == 0 {
+		return globProfiles(root, chromiumDefaultProfiles)
+	}
+
+	profiles := make([]models.Profile, 0, len(localState.Profile.InfoCache))
+	for id, info := range localState.Profile.InfoCache {
+		// the ID becomes a folder under root; anything that could leave it is skipped
+		if id == "" || id == "." || id == ".." || id != filepath.Base(id) || strings.ContainsAny(id, `/\`) {
+			continue
+		}
+		profiles = append(profiles, models.Profile{
+			ID:   id,
+			Name: info.Name,
+			Path: filepath.Join(root, id),
+		})
+	}
+	return profiles
+}
+
+// discoverFirefoxProfiles reads the [ProfileN] sections of profiles.ini,
+// falling back to every folder under Profiles. Profiles from profiles.ini are
+// identified by their Path entry, which is unique there even when two profile
+// folders share a name.
+func discoverFirefoxProfiles(root string) []models.Profile {
+	file, err := filesystem.Current().Open(filepath.Join(root, "profiles.ini"))
+	if err != nil {
+		return globProfiles(filepath.Join(root, "Profiles"), []string{"*"})
+	}
+	defer file.Close()
+
+	var profiles []models.Profile
+	var current map[string]string
+
+	flush := func() {
+		if current == nil || current["path"] == "" {
+			return
+		}
+
+		path := filepath.FromSlash(current["path"])
+		if current["isrelative"] != "0" {
+			path = filepath.Join(root, path)
+		}
+
+		profiles = append(profiles, models.Profile{
+			ID:   current["path"],
+			Name: current["name"],
+			Path: path,
+		})
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			current = nil
+			if strings.HasPrefix(strings.ToLower(line), "[profile") {
+				current = make(map[string]string)
+			}
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, "="); ok && current != nil {
+			current[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	flush()
+
+	return profiles
+}
+
+// globProfiles treats every folder under root matching one of the patterns as a profile
+func globProfiles(root string, patterns []string) []models.Profile {
+	var profiles []models.Profile
+	for _, pattern := range patterns {
+		matches, _ := filesystem.GlobAll(filesystem.Current(), filepath.Join(root, pattern))
+		for _, match := range matches {
+			profiles = append(profiles, models.Profile{
+				ID:   filepath.Base(match),
+				Path: match,
+			})
+		}
+	}
+	return profiles
+}
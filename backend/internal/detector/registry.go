@@ -0,0 +1,158 @@
+package detector
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Registry value types, numbered as in the Windows API
+const (
+	RegistryString       uint32 = 1  // REG_SZ
+	RegistryExpandString uint32 = 2  // REG_EXPAND_SZ
+	RegistryBinary       uint32 = 3  // REG_BINARY
+	RegistryDWord        uint32 = 4  // REG_DWORD
+	RegistryMultiString  uint32 = 7  // REG_MULTI_SZ
+	RegistryQWord        uint32 = 11 // REG_QWORD
+)
+
+// registryRootSeparator separates the root key and subkeys of a key path
+const registryRootSeparator = `\`
+
+// registryRoots maps accepted root key spellings to their short form
+var registryRoots = map[string]string{
+	"HKLM":                "HKLM",
+	"HKEY_LOCAL_MACHINE":  "HKLM",
+	"HKCU":                "HKCU",
+	"HKEY_CURRENT_USER":   "HKCU",
+	"HKCR":                "HKCR",
+	"HKEY_CLASSES_ROOT":   "HKCR",
+	"HKU":                 "HKU",
+	"HKEY_USERS":          "HKU",
+	"HKCC":                "HKCC",
+	"HKEY_CURRENT_CONFIG": "HKCC",
+}
+
+// RegistryValue is a named registry value with its raw data as stored by Windows
+// (UTF-16LE strings, little-endian integers). The default value has an empty name.
+type RegistryValue struct {
+	Name string
+	Type uint32
+	Data []byte
+}
+
+// RegistryReader gives read access to the Windows registry. Key paths start
+// with a root key such as HKCU or HKEY_LOCAL_MACHINE followed by a backslash.
+type RegistryReader interface {
+	// KeyExists reports whether the key can be opened
+	KeyExists(key string) bool
+	// ReadValue returns a value of the key; name "" is the default value
+	ReadValue(key string, name string) (RegistryValue, bool)
+	// SubKeys lists the names of the key's direct subkeys
+	SubKeys(key string) ([]string, error)
+	// Values lists the key's values, including the default value when set
+	Values(key string) ([]RegistryValue, error)
+}
+
+// RegistryWriter adds the changes registry cleaning actions make
+type RegistryWriter interface {
+	RegistryReader
+	// DeleteKey removes the key together with all its subkeys and values
+	DeleteKey(key string) error
+	// DeleteValue removes a single value of the key
+	DeleteValue(key string, name string) error
+}
+
+var (
+	registryMutex  sync.RWMutex
+	activeRegistry RegistryWriter = systemRegistry{}
+)
+
+// SetRegistry replaces the registry used by detection and registry cleaning
+// and returns the previous one, so tests can run against a MemoryRegistry
+func SetRegistry(registry RegistryWriter) RegistryWriter {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	previous := activeRegistry
+	activeRegistry = registry
+	return previous
+}
+
+// Registry returns the registry used by detection and registry cleaning
+func Registry() RegistryWriter {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	return activeRegistry
+}
+
+// SplitRegistryKey separates the root key (normalized to its short form, e.g.
+// "HKCU") from the subkey path. Reports false for unknown roots.
+func SplitRegistryKey(key string) (root string, subkey string, ok bool) {
+	rootName, subkey, _ := strings.Cut(strings.Trim(key, registryRootSeparator), registryRootSeparator)
+	root, ok = registryRoots[strings.ToUpper(rootName)]
+	return root, strings.Trim(subkey, registryRootSeparator), ok
+}
+
+// Text renders the value data for matching and display: strings as is,
+// multi-strings joined by newlines, integers in decimal and anything else in hex.
+func (value RegistryValue) Text() string {
+	switch value.Type {
+	case RegistryString, RegistryExpandString:
+		return decodeUTF16(value.Data)
+	case RegistryMultiString:
+		return strings.Join(value.Strings(), "\n")
+	case RegistryDWord:
+		if len(value.Data) >= 4 {
+			return strconv.FormatUint(uint64(binary.LittleEndian.Uint32(value.Data)), 10)
+		}
+	case RegistryQWord:
+		if len(value.Data) >= 8 {
+			return strconv.FormatUint(binary.LittleEndian.Uint64(value.Data), 10)
+		}
+	}
+	return hex.EncodeToString(value.Data)
+}
+
+// Strings splits a REG_MULTI_SZ value into its strings
+func (value RegistryValue) Strings() []string {
+	var items []string
+	for _, item := range strings.Split(decodeUTF16(value.Data), "\x00") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// StringValue builds a REG_SZ value
+func StringValue(name string, text string) RegistryValue {
+	return RegistryValue{Name: name, Type: RegistryString, Data: encodeUTF16(text)}
+}
+
+// DWordValue builds a REG_DWORD value
+func DWordValue(name string, number uint32) RegistryValue {
+	return RegistryValue{Name: name, Type: RegistryDWord, Data: binary.LittleEndian.AppendUint32(nil, number)}
+}
+
+// decodeUTF16 converts UTF-16LE data to a string, dropping the terminating NUL
+func decodeUTF16(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
+
+// encodeUTF16 converts a string to NUL-terminated UTF-16LE data
+func encodeUTF16(text string) []byte {
+	units := utf16.Encode([]rune(text + "\x00"))
+	data := make([]byte, 0, len(units)*2)
+	for _, unit := range units {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
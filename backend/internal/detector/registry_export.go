@@ -0,0 +1,133 @@
+package detector
+
+import (
+	"backend/internal/models"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// regFileHeader starts every exported .reg file
+const regFileHeader = "Windows Registry Editor Version 5.00"
+
+// registryLongRoots maps short root names to the spelling regedit uses in .reg files
+var registryLongRoots = map[string]string{
+	"HKLM": "HKEY_LOCAL_MACHINE",
+	"HKCU": "HKEY_CURRENT_USER",
+	"HKCR": "HKEY_CLASSES_ROOT",
+	"HKU":  "HKEY_USERS",
+	"HKCC": "HKEY_CURRENT_CONFIG",
+}
+
+// ExportRegistry writes the entries in the .reg format regedit imports
+// (UTF-16LE with a byte order mark, CRLF line endings), so they can be restored
+// by double-clicking the file. Entries without a value export the whole key
+// with its subkeys; entries with a value export just that value of the key.
+func ExportRegistry(w io.Writer, reader RegistryReader, entries []models.RegistryEntry) error {
+	var text strings.Builder
+	text.WriteString(regFileHeader + "\r\n")
+
+	var keyOrder []string
+	values := make(map[string][]string)
+	for _, entry := range entries {
+		if entry.Value == "" {
+			if err := exportKey(&text, reader, entry.Key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := values[entry.Key]; !ok {
+			keyOrder = append(keyOrder, entry.Key)
+		}
+		values[entry.Key] = append(values[entry.Key], entry.Value)
+	}
+
+	for _, key := range keyOrder {
+		writeRegKeyHeader(&text, key)
+		for _, name := range values[key] {
+			value, ok := reader.ReadValue(key, name)
+			if !ok {
+				return fmt.Errorf("registry value not found: %s\\%s", key, name)
+			}
+			writeRegValue(&text, value)
+		}
+	}
+
+	data := []byte{0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(text.String())) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+
+	_, err := w.Write(data)
+	return err
+}
+
+// exportKey writes a key, its values and then its subkeys recursively
+func exportKey(text *strings.Builder, reader RegistryReader, key string) error {
+	values, err := reader.Values(key)
+	if err != nil {
+		return err
+	}
+
+	writeRegKeyHeader(text, key)
+	for _, value := range values {
+		writeRegValue(text, value)
+	}
+
+	subKeys, err := reader.SubKeys(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range subKeys {
+		if err := exportKey(text, reader, key+registryRootSeparator+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeRegKeyHeader(text *strings.Builder, key string) {
+	root, subkey, _ := SplitRegistryKey(key)
+	path := registryLongRoots[root]
+	if subkey != "" {
+		path += registryRootSeparator + subkey
+	}
+	fmt.Fprintf(text, "\r\n[%s]\r\n", path)
+}
+
+// writeRegValue writes `"name"=data`, with strings and DWORDs in their
+// readable form and every other type as hex(type) bytes
+func writeRegValue(text *strings.Builder, value RegistryValue) {
+	if value.Name == "" {
+		text.WriteString("@=")
+	} else {
+		fmt.Fprintf(text, "%s=", quoteRegString(value.Name))
+	}
+
+	switch {
+	case value.Type == RegistryString:
+		text.WriteString(quoteRegString(decodeUTF16(value.Data)))
+	case value.Type == RegistryDWord && len(value.Data) == 4:
+		fmt.Fprintf(text, "dword:%08x", binary.LittleEndian.Uint32(value.Data))
+	default:
+		if value.Type == RegistryBinary {
+			text.WriteString("hex:")
+		} else {
+			fmt.Fprintf(text, "hex(%x):", value.Type)
+		}
+		for i, b := range value.Data {
+			if i > 0 {
+				text.WriteByte(',')
+			}
+			fmt.Fprintf(text, "%02x", b)
+		}
+	}
+	text.WriteString("\r\n")
+}
+
+func quoteRegString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+}
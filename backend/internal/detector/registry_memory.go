@@ -0,0 +1,189 @@
+package detector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryRegistry is an in-memory RegistryWriter for tests and for running
+// detection off Windows. Key and value names are case-insensitive, as in Windows.
+type MemoryRegistry struct {
+	mutex sync.RWMutex
+	keys  map[string]*memoryKey // keyed by lower-cased canonical path
+}
+
+type memoryKey struct {
+	path   string                   // canonical path, e.g. HKCU\Software\App
+	values map[string]RegistryValue // keyed by lower-cased value name
+}
+
+// NewMemoryRegistry returns an empty registry
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{keys: make(map[string]*memoryKey)}
+}
+
+// AddKey creates a key and its missing parents. Keys under unknown roots are ignored.
+func (r *MemoryRegistry) AddKey(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.addKey(key)
+}
+
+// SetValue creates the key if needed and stores the value in it
+func (r *MemoryRegistry) SetValue(key string, value RegistryValue) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if entry := r.addKey(key); entry != nil {
+		entry.values[strings.ToLower(value.Name)] = value
+	}
+}
+
+// KeyExists reports whether the key was added
+func (r *MemoryRegistry) KeyExists(key string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.lookup(key) != nil
+}
+
+// ReadValue returns a stored value of the key
+func (r *MemoryRegistry) ReadValue(key string, name string) (RegistryValue, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry := r.lookup(key)
+	if entry == nil {
+		return RegistryValue{}, false
+	}
+	value, ok := entry.values[strings.ToLower(name)]
+	return value, ok
+}
+
+// SubKeys lists the names of the key's direct subkeys, sorted
+func (r *MemoryRegistry) SubKeys(key string) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry := r.lookup(key)
+	if entry == nil {
+		return nil, fmt.Errorf("registry key not found: %s", key)
+	}
+
+	prefix := strings.ToLower(entry.path) + registryRootSeparator
+	var names []string
+	for id, child := range r.keys {
+		if rest, ok := strings.CutPrefix(id, prefix); ok && !strings.Contains(rest, registryRootSeparator) {
+			names = append(names, child.path[len(prefix):])
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Values lists the key's values sorted by name
+func (r *MemoryRegistry) Values(key string) ([]RegistryValue, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry := r.lookup(key)
+	if entry == nil {
+		return nil, fmt.Errorf("registry key not found: %s", key)
+	}
+
+	values := make([]RegistryValue, 0, len(entry.values))
+	for _, value := range entry.values {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+	return values, nil
+}
+
+// DeleteKey removes the key and everything beneath it. Roots are refused,
+// like the system registry does.
+func (r *MemoryRegistry) DeleteKey(key string) error {
+	if _, subkey, ok := SplitRegistryKey(key); !ok || subkey == "" {
+		return fmt.Errorf("refusing to delete registry root: %s", key)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry := r.lookup(key)
+	if entry == nil {
+		return fmt.Errorf("registry key not found: %s", key)
+	}
+
+	id := strings.ToLower(entry.path)
+	for other := range r.keys {
+		if other == id || strings.HasPrefix(other, id+registryRootSeparator) {
+			delete(r.keys, other)
+		}
+	}
+	return nil
+}
+
+// DeleteValue removes a value of the key
+func (r *MemoryRegistry) DeleteValue(key string, name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry := r.lookup(key)
+	if entry == nil {
+		return fmt.Errorf("registry key not found: %s", key)
+	}
+	if _, ok := entry.values[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("registry value not found: %s\\%s", key, name)
+	}
+
+	delete(entry.values, strings.ToLower(name))
+	return nil
+}
+
+func (r *MemoryRegistry) addKey(key string) *memoryKey {
+	canonical, ok := canonicalRegistryKey(key)
+	if !ok {
+		return nil
+	}
+
+	var path string
+	var entry *memoryKey
+	for i, part := range strings.Split(canonical, registryRootSeparator) {
+		if i > 0 {
+			path += registryRootSeparator
+		}
+		path += part
+
+		id := strings.ToLower(path)
+		if entry = r.keys[id]; entry == nil {
+			entry = &memoryKey{path: path, values: make(map[string]RegistryValue)}
+			r.keys[id] = entry
+		}
+	}
+	return entry
+}
+
+func (r *MemoryRegistry) lookup(key string) *memoryKey {
+	id, ok := canonicalRegistryKey(key)
+	if !ok {
+		return nil
+	}
+	return r.keys[strings.ToLower(id)]
+}
+
+// canonicalRegistryKey returns the key path with a short root and no empty parts
+func canonicalRegistryKey(key string) (string, bool) {
+	root, subkey, ok := SplitRegistryKey(key)
+	if !ok {
+		return "", false
+	}
+
+	path := root
+	for _, part := range strings.Split(subkey, registryRootSeparator) {
+		if part != "" {
+			path += registryRootSeparator + part
+		}
+	}
+	return path, true
+}
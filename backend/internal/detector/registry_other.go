@@ -0,0 +1,24 @@
+//go:build !windows
+
+package detector
+
+import "errors"
+
+// errNoRegistry is returned for registry access on systems without one
+var errNoRegistry = errors.New("registry is only available on Windows")
+
+// systemRegistry stands in for the Windows registry on other systems, where
+// no key exists
+type systemRegistry struct{}
+
+func (systemRegistry) KeyExists(string) bool { return false }
+
+func (systemRegistry) ReadValue(string, string) (RegistryValue, bool) { return RegistryValue{}, false }
+
+func (systemRegistry) SubKeys(key string) ([]string, error) { return nil, errNoRegistry }
+
+func (systemRegistry) Values(key string) ([]RegistryValue, error) { return nil, errNoRegistry }
+
+func (systemRegistry) DeleteKey(key string) error { return errNoRegistry }
+
+func (systemRegistry) DeleteValue(key string, name string) error { return errNoRegistry }
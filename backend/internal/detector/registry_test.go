@@ -0,0 +1,107 @@
+package detector
+
+import (
+	"backend/internal/models"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckRegistry(t *testing.T) {
+	registry := useMemoryRegistry(t)
+	registry.AddKey(`HKCU\Software\Empty`)
+	registry.SetValue(`HKCU\Software\Vendor\App`, StringValue("InstallPath", `C:\Program Files\App`))
+	registry.SetValue(`HKCU\Software\Vendor\App`, DWordValue("Build", 1042))
+	registry.SetValue(`HKCU\Software\Vendor\App`, StringValue("", "App 2.1"))
+
+	tests := []struct {
+		name  string
+		check models.RegistryCheck
+		want  bool
+	}{
+		{"key exists", models.RegistryCheck{Key: `HKCU\Software\Empty`}, true},
+		{"long root and other case", models.RegistryCheck{Key: `HKEY_CURRENT_USER\software\vendor\APP`}, true},
+		{"parent key exists", models.RegistryCheck{Key: `HKCU\Software\Vendor`}, true},
+		{"missing key", models.RegistryCheck{Key: `HKCU\Software\Other`}, false},
+		{"unknown root", models.RegistryCheck{Key: `HKXX\Software\Vendor\App`}, false},
+
+		{"value exists", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "InstallPath"}, true},
+		{"value name case", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "installpath"}, true},
+		{"missing value", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "Uninstall"}, false},
+		{"value of a key without values", models.RegistryCheck{Key: `HKCU\Software\Empty`, Value: "InstallPath"}, false},
+
+		{"string pattern", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "InstallPath", Pattern: `(?i)program files\\App$`}, true},
+		{"string pattern mismatch", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "InstallPath", Pattern: `^D:`}, false},
+		{"dword pattern", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "Build", Pattern: `^10\d\d$`}, true},
+		{"default value pattern", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Pattern: `^App 2\.`}, true},
+		{"default value pattern mismatch", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Pattern: `^App 3\.`}, false},
+		{"bad pattern", models.RegistryCheck{Key: `HKCU\Software\Vendor\App`, Value: "InstallPath", Pattern: "("}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, ok := checkRegistry(test.check)
+			if ok != test.want {
+				t.Errorf("got %v (rule %v), want %v", ok, rule, test.want)
+			}
+			if ok && rule.ID == "" {
+				t.Error("match without a rule")
+			}
+		})
+	}
+}
+
+func TestDetectRegistryOS(t *testing.T) {
+	registry := useMemoryRegistry(t)
+	registry.AddKey(`HKCU\Software\Vendor\App`)
+
+	otherOS := "windows"
+	if runtime.GOOS == "windows" {
+		otherOS = "linux"
+	}
+
+	tests := []struct {
+		name string
+		os   []string
+		want bool
+	}{
+		{"any OS", nil, true},
+		{"this OS", []string{runtime.GOOS}, true},
+		{"this OS in upper case", []string{otherOS, strings.ToUpper(runtime.GOOS)}, true},
+		{"other OS only", []string{otherOS}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsOSSupported(test.os); got != test.want {
+				t.Errorf("IsOSSupported(%v) = %v, want %v", test.os, got, test.want)
+			}
+
+			detection := models.Detection{Type: "registry", Registry: []models.RegistryCheck{{Key: `HKCU\Software\Vendor\App`, OS: test.os}}}
+			if result := Detect(detection); result.Installed != test.want {
+				t.Errorf("installed %v (rule %q), want %v", result.Installed, result.Rule, test.want)
+			}
+		})
+	}
+}
+
+func TestMemoryRegistryDeleteKeyRefusesRoot(t *testing.T) {
+	registry := useMemoryRegistry(t)
+	registry.SetValue(`HKCU\Software\Vendor\App`, StringValue("InstallPath", `C:\App`))
+
+	for _, key := range []string{`HKCU`, `HKEY_CURRENT_USER\`, `HKXX\Software`} {
+		if err := registry.DeleteKey(key); err == nil {
+			t.Errorf("DeleteKey(%q) succeeded", key)
+		}
+	}
+	if !registry.KeyExists(`HKCU\Software\Vendor\App`) {
+		t.Error("subkeys deleted although the root was refused")
+	}
+
+	if err := registry.DeleteKey(`HKCU\Software\Vendor`); err != nil {
+		t.Fatal(err)
+	}
+	if registry.KeyExists(`HKCU\Software\Vendor\App`) {
+		t.Error("subkey left after deleting its parent")
+	}
+}
@@ -0,0 +1,119 @@
+package detector
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// systemRegistry reads the registry of this machine
+type systemRegistry struct{}
+
+var systemRegistryRoots = map[string]registry.Key{
+	"HKLM": registry.LOCAL_MACHINE,
+	"HKCU": registry.CURRENT_USER,
+	"HKCR": registry.CLASSES_ROOT,
+	"HKU":  registry.USERS,
+	"HKCC": registry.CURRENT_CONFIG,
+}
+
+func (systemRegistry) KeyExists(key string) bool {
+	k, err := openSystemKey(key, registry.QUERY_VALUE)
+	if err != nil {
+		return false
+	}
+	defer k.Close()
+
+	return true
+}
+
+func (systemRegistry) ReadValue(key string, name string) (RegistryValue, bool) {
+	k, err := openSystemKey(key, registry.QUERY_VALUE)
+	if err != nil {
+		return RegistryValue{}, false
+	}
+	defer k.Close()
+
+	size, valueType, err := k.GetValue(name, nil)
+	if err != nil && !errors.Is(err, registry.ErrShortBuffer) {
+		return RegistryValue{}, false
+	}
+
+	data := make([]byte, size)
+	if _, _, err := k.GetValue(name, data); err != nil {
+		return RegistryValue{}, false
+	}
+
+	return RegistryValue{Name: name, Type: valueType, Data: data}, true
+}
+
+func (systemRegistry) SubKeys(key string) ([]string, error) {
+	k, err := openSystemKey(key, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+
+	return k.ReadSubKeyNames(-1)
+}
+
+func (r systemRegistry) Values(key string) ([]RegistryValue, error) {
+	k, err := openSystemKey(key, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	names, err := k.ReadValueNames(-1)
+	k.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]RegistryValue, 0, len(names))
+	for _, name := range names {
+		if value, ok := r.ReadValue(key, name); ok {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// DeleteKey removes the subkeys depth-first, as Windows only deletes empty keys.
+// A root is refused before anything beneath it is touched.
+func (r systemRegistry) DeleteKey(key string) error {
+	root, subkey, ok := SplitRegistryKey(key)
+	if !ok || subkey == "" {
+		return errors.New("refusing to delete registry root: " + key)
+	}
+
+	subKeys, err := r.SubKeys(key)
+	if err != nil {
+		return err
+	}
+	for _, name := range subKeys {
+		if err := r.DeleteKey(key + registryRootSeparator + name); err != nil {
+			return err
+		}
+	}
+
+	return registry.DeleteKey(systemRegistryRoots[root], subkey)
+}
+
+func (systemRegistry) DeleteValue(key string, name string) error {
+	k, err := openSystemKey(key, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	return k.DeleteValue(name)
+}
+
+// openSystemKey opens a key given as ROOT\sub\key
+func openSystemKey(key string, access uint32) (registry.Key, error) {
+	root, subkey, ok := SplitRegistryKey(key)
+	if !ok {
+		return 0, errors.New("unknown registry root: " + key)
+	}
+
+	return registry.OpenKey(systemRegistryRoots[root], subkey, access)
+}
@@ -0,0 +1,312 @@
+package detector
+
+import (
+	"backend/internal/filesystem"
+	"backend/internal/i18n"
+	"backend/internal/models"
+	"bufio"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDetectionFileSize caps how much of a file content-based rules read
+const maxDetectionFileSize = 1 << 20
+
+// defaultVersionPattern extracts the first dotted number from a file
+var defaultVersionPattern = regexp.MustCompile(`(\d+(?:\.\d+)+)`)
+
+// Detect evaluates a detection rule and explains the outcome.
+//
+// Leaf rules match when any of their paths satisfies the type's check
+// (paths may contain wildcards) or when any registry check passes: the key
+// exists or, with value/pattern set, the value exists and its text matches.
+// A leaf with neither paths nor registry keys always matches, as does "always".
+func Detect(detection models.Detection) models.DetectionResult {
+	switch detection.Type {
+	case "always":
+		return ruleResult(true, models.Message{ID: i18n.MsgRuleAlways})
+	case "all":
+		return detectAll(detection.Rules)
+	case "any":
+		return detectAny(detection.Rules)
+	case "not":
+		result := detectAny(detection.Rules)
+		if result.Installed {
+			return ruleResult(false, models.Message{ID: i18n.MsgRuleNot, Args: []any{resultMessage(result)}})
+		}
+		return ruleResult(true, models.Message{ID: i18n.MsgRuleNotNone})
+	}
+
+	if len(detection.Paths) == 0 && len(detection.Registry) == 0 {
+		return ruleResult(true, models.Message{ID: i18n.MsgRuleAlways})
+	}
+
+	for _, path := range detection.Paths {
+		if rule, ok := checkLeaf(detection, path); ok {
+			return ruleResult(true, rule)
+		}
+	}
+
+	for _, reg := range detection.Registry {
+		if !IsOSSupported(reg.OS) {
+			continue
+		}
+		if rule, ok := checkRegistry(reg); ok {
+			return ruleResult(true, rule)
+		}
+	}
+
+	return models.DetectionResult{Installed: false}
+}
+
+// ruleResult describes the rule that decided the outcome, with its English
+// text in Rule and its message for localization in RuleID and RuleArgs
+func ruleResult(installed bool, rule models.Message) models.DetectionResult {
+	return models.DetectionResult{
+		Installed: installed,
+		Rule:      i18n.Message(i18n.DefaultLocale, rule.ID, rule.Args...),
+		RuleID:    rule.ID,
+		RuleArgs:  rule.Args,
+	}
+}
+
+// resultMessage returns the message describing the rule of a result
+func resultMessage(result models.DetectionResult) models.Message {
+	return models.Message{ID: result.RuleID, Args: result.RuleArgs}
+}
+
+// detectAll matches when every rule matches
+func detectAll(rules []models.Detection) models.DetectionResult {
+	matched := make([]models.Message, 0, len(rules))
+	for _, rule := range rules {
+		result := Detect(rule)
+		if !result.Installed {
+			return models.DetectionResult{Installed: false}
+		}
+		matched = append(matched, resultMessage(result))
+	}
+	return ruleResult(true, models.Message{ID: i18n.MsgRuleAll, Args: []any{matched}})
+}
+
+// detectAny matches when at least one rule matches, reporting the first match
+func detectAny(rules []models.Detection) models.DetectionResult {
+	for _, rule := range rules {
+		if result := Detect(rule); result.Installed {
+			return ruleResult(true, models.Message{ID: i18n.MsgRuleAny, Args: []any{resultMessage(result)}})
+		}
+	}
+	return models.DetectionResult{Installed: false}
+}
+
+// checkLeaf applies a leaf rule to one configured path, returning the
+// description of the match
+func checkLeaf(detection models.Detection, path string) (models.Message, bool) {
+	if detection.Type == "executable" {
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return models.Message{}, false
+		}
+		return models.Message{ID: i18n.MsgRuleExecutable, Args: []any{found}}, true
+	}
+
+	for _, candidate := range expandCandidates(path) {
+		info, err := filesystem.Current().Stat(candidate)
+		if err != nil {
+			continue
+		}
+
+		switch detection.Type {
+		case "file":
+			if info.Mode().IsRegular() {
+				return models.Message{ID: i18n.MsgRuleFile, Args: []any{candidate}}, true
+			}
+		case "dir":
+			if info.IsDir() {
+				return models.Message{ID: i18n.MsgRuleDir, Args: []any{candidate}}, true
+			}
+		case "contains":
+			if info.Mode().IsRegular() && fileContains(candidate, detection.Pattern) {
+				return models.Message{ID: i18n.MsgRuleContains, Args: []any{detection.Pattern, candidate}}, true
+			}
+		case "ini_key":
+			if value, ok := readINIKey(candidate, detection.Key); ok && valueMatches(value, detection.Pattern) {
+				return models.Message{ID: i18n.MsgRuleINIKey, Args: []any{detection.Key, candidate}}, true
+			}
+		case "json_key":
+			if value, ok := readJSONKey(candidate, detection.Key); ok && valueMatches(value, detection.Pattern) {
+				return models.Message{ID: i18n.MsgRuleJSONKey, Args: []any{detection.Key, candidate}}, true
+			}
+		case "version":
+			if version, ok := readVersion(candidate, detection.Pattern); ok && CompareVersions(version, detection.MinVersion) >= 0 {
+				return models.Message{ID: i18n.MsgRuleVersion, Args: []any{version, detection.MinVersion, candidate}}, true
+			}
+		default:
+			// "path", "registry" and untyped rules only need the path to exist
+			return models.Message{ID: i18n.MsgRulePath, Args: []any{candidate}}, true
+		}
+	}
+
+	return models.Message{}, false
+}
+
+// expandCandidates expands environment variables and wildcards in a detection path
+func expandCandidates(path string) []string {
+	expanded := ExpandPath(path)
+	if !filesystem.HasMeta(expanded) {
+		return []string{expanded}
+	}
+
+	matches, _ := filesystem.GlobAll(filesystem.Current(), expanded)
+	return matches
+}
+
+// readHead returns up to maxDetectionFileSize bytes of a file
+func readHead(path string) ([]byte, bool) {
+	file, err := filesystem.Current().Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxDetectionFileSize))
+	return data, err == nil
+}
+
+func fileContains(path string, pattern string) bool {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+
+	data, ok := readHead(path)
+	return ok && re.Match(data)
+}
+
+// valueMatches reports whether value matches the optional pattern
+func valueMatches(value string, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	re, err := regexp.Compile(pattern)
+	return err == nil && re.MatchString(value)
+}
+
+// readINIKey looks up "Section.Key" (or a bare "Key" before any section) in
+// an INI file. Section and key names are case-insensitive.
+func readINIKey(path string, key string) (string, bool) {
+	data, ok := readHead(path)
+	if !ok {
+		return "", false
+	}
+
+	wantSection, wantKey := "", key
+	if section, name, found := strings.Cut(key, "."); found {
+		wantSection, wantKey = section, name
+	}
+
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if found && strings.EqualFold(section, wantSection) && strings.EqualFold(strings.TrimSpace(name), wantKey) {
+			return strings.TrimSpace(value), true
+		}
+	}
+
+	return "", false
+}
+
+// readJSONKey follows a dotted path of object keys in a JSON file and
+// returns the value found there in its JSON form (strings unquoted).
+func readJSONKey(path string, key string) (string, bool) {
+	data, ok := readHead(path)
+	if !ok {
+		return "", false
+	}
+
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "", false
+	}
+
+	current := document
+	for _, part := range strings.Split(key, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if current, ok = object[part]; !ok {
+			return "", false
+		}
+	}
+
+	if text, ok := current.(string); ok {
+		return text, true
+	}
+	encoded, err := json.Marshal(current)
+	return string(encoded), err == nil
+}
+
+// readVersion extracts a version from a file using the first capture group of
+// pattern, or the first dotted number when pattern is empty.
+func readVersion(path string, pattern string) (string, bool) {
+	re := defaultVersionPattern
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return "", false
+		}
+	}
+
+	data, ok := readHead(path)
+	if !ok {
+		return "", false
+	}
+
+	match := re.FindSubmatch(data)
+	if len(match) < 2 {
+		return "", false
+	}
+	return string(match[1]), true
+}
+
+// CompareVersions compares dotted numeric versions segment by segment,
+// treating missing segments as zero. Returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	partsA := strings.Split(a, ".")
+	partsB := strings.Split(b, ".")
+
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var numberA, numberB int
+		if i < len(partsA) {
+			numberA, _ = strconv.Atoi(partsA[i])
+		}
+		if i < len(partsB) {
+			numberB, _ = strconv.Atoi(partsB[i])
+		}
+
+		if numberA != numberB {
+			if numberA < numberB {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,123 @@
+package detector
+
+import (
+	"backend/internal/models"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDetectRules(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("app", "app.log"), []byte("started\nlicense: pro-edition\n"))
+	memory.WriteFile(testPath("app", "settings.ini"), []byte("; comment\ntop=1\n[General]\nTheme = Dark\n[Update]\nchannel=beta\n"))
+	memory.WriteFile(testPath("app", "config.json"), []byte(`{"profile": {"name": "work", "count": 3, "sync": true, "tags": ["a"]}}`))
+	memory.WriteFile(testPath("app", "version.txt"), []byte("engine 0.5 release 1.10.2\n"))
+	memory.MkdirAll(testPath("app", "cache"))
+
+	file := func(elements ...string) []string { return []string{testPath(elements...)} }
+	tests := []struct {
+		name      string
+		detection models.Detection
+		want      bool
+	}{
+		{"file is a file", models.Detection{Type: "file", Paths: file("app", "app.log")}, true},
+		{"file is not a dir", models.Detection{Type: "file", Paths: file("app", "cache")}, false},
+		{"dir is a dir", models.Detection{Type: "dir", Paths: file("app", "cache")}, true},
+		{"dir is not a file", models.Detection{Type: "dir", Paths: file("app", "app.log")}, false},
+		{"path is either", models.Detection{Type: "path", Paths: file("app", "app.log")}, true},
+		{"missing path", models.Detection{Type: "path", Paths: file("app", "missing")}, false},
+		{"wildcard file", models.Detection{Type: "file", Paths: file("app", "*.log")}, true},
+		{"no paths always match", models.Detection{Type: "dir"}, true},
+
+		{"all match", models.Detection{Type: "all", Rules: []models.Detection{
+			{Type: "file", Paths: file("app", "app.log")}, {Type: "dir", Paths: file("app", "cache")}}}, true},
+		{"all with a miss", models.Detection{Type: "all", Rules: []models.Detection{
+			{Type: "file", Paths: file("app", "app.log")}, {Type: "dir", Paths: file("app", "missing")}}}, false},
+		{"any with a match", models.Detection{Type: "any", Rules: []models.Detection{
+			{Type: "dir", Paths: file("app", "missing")}, {Type: "dir", Paths: file("app", "cache")}}}, true},
+		{"any without a match", models.Detection{Type: "any", Rules: []models.Detection{
+			{Type: "dir", Paths: file("app", "missing")}}}, false},
+		{"not of a miss", models.Detection{Type: "not", Rules: []models.Detection{
+			{Type: "dir", Paths: file("app", "missing")}}}, true},
+		{"not of a match", models.Detection{Type: "not", Rules: []models.Detection{
+			{Type: "dir", Paths: file("app", "cache")}}}, false},
+
+		{"contains", models.Detection{Type: "contains", Paths: file("app", "app.log"), Pattern: `license: \w+-edition`}, true},
+		{"contains no match", models.Detection{Type: "contains", Paths: file("app", "app.log"), Pattern: "trial"}, false},
+		{"contains bad pattern", models.Detection{Type: "contains", Paths: file("app", "app.log"), Pattern: "("}, false},
+		{"contains on a dir", models.Detection{Type: "contains", Paths: file("app", "cache"), Pattern: ""}, false},
+
+		{"ini section key", models.Detection{Type: "ini_key", Paths: file("app", "settings.ini"), Key: "General.Theme"}, true},
+		{"ini case-insensitive", models.Detection{Type: "ini_key", Paths: file("app", "settings.ini"), Key: "general.THEME", Pattern: "^Dark$"}, true},
+		{"ini key before sections", models.Detection{Type: "ini_key", Paths: file("app", "settings.ini"), Key: "top"}, true},
+		{"ini key in another section", models.Detection{Type: "ini_key", Paths: file("app", "settings.ini"), Key: "General.channel"}, false},
+		{"ini value mismatch", models.Detection{Type: "ini_key", Paths: file("app", "settings.ini"), Key: "Update.channel", Pattern: "^stable$"}, false},
+
+		{"json nested key", models.Detection{Type: "json_key", Paths: file("app", "config.json"), Key: "profile.name", Pattern: "^work$"}, true},
+		{"json number", models.Detection{Type: "json_key", Paths: file("app", "config.json"), Key: "profile.count", Pattern: "^3$"}, true},
+		{"json bool", models.Detection{Type: "json_key", Paths: file("app", "config.json"), Key: "profile.sync", Pattern: "^true$"}, true},
+		{"json array", models.Detection{Type: "json_key", Paths: file("app", "config.json"), Key: "profile.tags", Pattern: `^\["a"\]$`}, true},
+		{"json missing key", models.Detection{Type: "json_key", Paths: file("app", "config.json"), Key: "profile.email"}, false},
+		{"json through a non-object", models.Detection{Type: "json_key", Paths: file("app", "config.json"), Key: "profile.name.first"}, false},
+		{"json in a non-json file", models.Detection{Type: "json_key", Paths: file("app", "app.log"), Key: "profile"}, false},
+
+		{"version default pattern", models.Detection{Type: "version", Paths: file("app", "version.txt"), MinVersion: "1.9"}, false},
+		{"version custom pattern", models.Detection{Type: "version", Paths: file("app", "version.txt"), Pattern: `release (\S+)`, MinVersion: "1.9"}, true},
+		{"version too old", models.Detection{Type: "version", Paths: file("app", "version.txt"), Pattern: `release (\S+)`, MinVersion: "1.11"}, false},
+		{"version pattern without a group", models.Detection{Type: "version", Paths: file("app", "version.txt"), Pattern: `release \S+`}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Detect(test.detection)
+			if result.Installed != test.want {
+				t.Errorf("installed %v (rule %q), want %v", result.Installed, result.Rule, test.want)
+			}
+			if result.Installed && result.Rule == "" {
+				t.Error("match without a rule")
+			}
+		})
+	}
+}
+
+func TestDetectExecutable(t *testing.T) {
+	dir := t.TempDir()
+	name := "cleaner-test-tool"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	if result := Detect(models.Detection{Type: "executable", Paths: []string{"cleaner-test-tool"}}); !result.Installed {
+		t.Error("executable in PATH not found")
+	}
+	if result := Detect(models.Detection{Type: "executable", Paths: []string{"cleaner-missing-tool"}}); result.Installed {
+		t.Errorf("missing executable found: %q", result.Rule)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"2.0", "2", 0},
+		{"2", "2.0.1", -1},
+		{"2.0.1", "2", 1},
+		{"10.0.0", "9.99.99", 1},
+		{"1.2.3", "1.2.3", 0},
+		{"", "0", 0},
+	}
+	for _, test := range tests {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package filesystem
+
+import "io/fs"
+
+// DefaultClusterSize is the allocation unit assumed when the file system
+// doesn't report how much space a file takes
+const DefaultClusterSize = 4096
+
+// AllocatedSize returns the bytes the file described by info occupies on
+// disk. Sparse files may take less than their size, small files take at least
+// one cluster.
+func AllocatedSize(path string, info fs.FileInfo) uint64 {
+	if allocated, ok := systemAllocatedSize(path, info); ok {
+		return allocated
+	}
+	return roundToCluster(info.Size(), DefaultClusterSize)
+}
+
+// roundToCluster rounds size up to a whole number of clusters
+func roundToCluster(size int64, cluster uint64) uint64 {
+	if size <= 0 {
+		return 0
+	}
+	return (uint64(size) + cluster - 1) / cluster * cluster
+}
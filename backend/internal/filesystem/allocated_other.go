@@ -0,0 +1,17 @@
+//go:build !windows
+
+package filesystem
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// systemAllocatedSize returns st_blocks, which counts 512-byte units
+func systemAllocatedSize(_ string, info fs.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Blocks) * 512, true
+}
@@ -0,0 +1,50 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestAllocatedSizeMemory(t *testing.T) {
+	memory := NewMemory()
+	memory.WriteFile("/empty", nil)
+	memory.WriteFile("/small", []byte("1"))
+	memory.WriteFile("/cluster", make([]byte, DefaultClusterSize+1))
+
+	tests := map[string]uint64{"/empty": 0, "/small": DefaultClusterSize, "/cluster": 2 * DefaultClusterSize}
+	for name, want := range tests {
+		info, err := memory.Lstat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := AllocatedSize(name, info); got != want {
+			t.Errorf("%s: got %d, want %d", name, got, want)
+		}
+	}
+}
+
+func TestAllocatedSizeSparseFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sizes are rounded to clusters on Windows")
+	}
+
+	name := filepath.Join(t.TempDir(), "sparse")
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if err := os.Truncate(name, 64<<20); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := OS{}.Lstat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocated := AllocatedSize(name, info); allocated >= uint64(info.Size()) {
+		t.Errorf("sparse file of %d bytes allocates %d", info.Size(), allocated)
+	}
+}
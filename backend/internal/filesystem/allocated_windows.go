@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGetDiskFreeSpace = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetDiskFreeSpaceW")
+
+// clusterSizes caches the cluster size per volume root
+var clusterSizes sync.Map
+
+// systemAllocatedSize rounds the size up to the cluster size of the file's volume
+func systemAllocatedSize(path string, info fs.FileInfo) (uint64, bool) {
+	if _, ok := info.Sys().(*syscall.Win32FileAttributeData); !ok {
+		return 0, false
+	}
+	return roundToCluster(info.Size(), clusterSize(path)), true
+}
+
+// clusterSize asks the volume holding path for its allocation unit
+func clusterSize(path string) uint64 {
+	root := filepath.VolumeName(path)
+	if root == "" {
+		return DefaultClusterSize
+	}
+	root = strings.TrimSuffix(root, `\`) + `\`
+	if size, ok := clusterSizes.Load(root); ok {
+		return size.(uint64)
+	}
+
+	size := uint64(DefaultClusterSize)
+	if name, err := windows.UTF16PtrFromString(root); err == nil {
+		var sectorsPerCluster, bytesPerSector, freeClusters, totalClusters uint32
+		ok, _, _ := procGetDiskFreeSpace.Call(uintptr(unsafe.Pointer(name)),
+			uintptr(unsafe.Pointer(&sectorsPerCluster)), uintptr(unsafe.Pointer(&bytesPerSector)),
+			uintptr(unsafe.Pointer(&freeClusters)), uintptr(unsafe.Pointer(&totalClusters)))
+		if ok != 0 && sectorsPerCluster*bytesPerSector != 0 {
+			size = uint64(sectorsPerCluster) * uint64(bytesPerSector)
+		}
+	}
+
+	clusterSizes.Store(root, size)
+	return size
+}
@@ -0,0 +1,129 @@
+// Package filesystem abstracts the file operations cleaner discovery and
+// cleaning perform, so they can run against an in-memory tree in tests.
+//
+// Paths are host paths (C:\Users\... on Windows), not io/fs slash paths.
+// Code reads and changes files through Current(), which is the real file
+// system unless a test swapped it with Set.
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+)
+
+// FS gives read access to files and directories
+type FS interface {
+	// Stat describes the named file, following symlinks
+	Stat(name string) (fs.FileInfo, error)
+	// Lstat describes the named file without following a final symlink
+	Lstat(name string) (fs.FileInfo, error)
+	// ReadDir lists a directory sorted by file name
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+	// Open opens a file for reading, so only part of it needs to be read
+	Open(name string) (io.ReadCloser, error)
+}
+
+// MutableFS adds the changes cleaning makes
+type MutableFS interface {
+	FS
+	// Remove deletes a file, symlink or empty directory
+	Remove(name string) error
+	Truncate(name string, size int64) error
+	// Overwrite writes over a regular file in place without changing its size,
+	// filling each block with fill, and flushes the data to the device
+	Overwrite(name string, fill func(block []byte)) error
+	// MkdirAll creates a directory and its missing parents like os.MkdirAll
+	MkdirAll(name string) error
+	// CreateTemp creates a new file in dir like os.CreateTemp
+	CreateTemp(dir string, pattern string) (WritableFile, error)
+	// FreeSpace returns the bytes the process may still write to the volume holding name
+	FreeSpace(name string) (uint64, error)
+}
+
+// WritableFile is a file created by MutableFS.CreateTemp
+type WritableFile interface {
+	io.Writer
+	Name() string
+	// Sync flushes the written data to the device
+	Sync() error
+	Close() error
+}
+
+var (
+	mutex   sync.RWMutex
+	current MutableFS = OS{}
+)
+
+// Set replaces the file system used by discovery and cleaning and returns the
+// previous one
+func Set(fsys MutableFS) MutableFS {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	previous := current
+	current = fsys
+	return previous
+}
+
+// Current returns the file system used by discovery and cleaning
+func Current() MutableFS {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// Exists reports whether name can be stat'ed
+func Exists(fsys FS, name string) bool {
+	_, err := fsys.Stat(name)
+	return err == nil
+}
+
+// WalkDir walks the tree rooted at root like filepath.WalkDir: in lexical
+// order, without following symlinks, honouring fs.SkipDir and fs.SkipAll.
+func WalkDir(fsys FS, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func walkDir(fsys FS, path string, entry fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, entry, nil); err != nil || !entry.IsDir() {
+		if errors.Is(err, fs.SkipDir) && entry.IsDir() {
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		// report the read error against the directory itself
+		if err = fn(path, entry, err); err != nil {
+			if errors.Is(err, fs.SkipDir) && entry.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	for _, child := range entries {
+		if err := walkDir(fsys, filepath.Join(path, child.Name()), child, fn); err != nil {
+			if errors.Is(err, fs.SkipDir) {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !linux && !darwin && !freebsd
+
+package filesystem
+
+import "errors"
+
+// freeSpace can't tell anything on this platform
+func freeSpace(string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd
+
+package filesystem
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the blocks available to unprivileged users
+func freeSpace(name string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(name, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
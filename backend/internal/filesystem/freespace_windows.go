@@ -0,0 +1,17 @@
+package filesystem
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the bytes available to the caller, which honours disk quotas
+func freeSpace(name string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,232 @@
+package filesystem
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// globStar matches any number of directories, including none
+const globStar = "**"
+
+// HasMeta reports whether path contains glob syntax
+func HasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[{")
+}
+
+// WalkGlob calls fn for every path matching pattern, as soon as it is found.
+//
+// Patterns are filepath.Match patterns with two additions: a "**" segment
+// matches any number of directories, including none, and {a,b} matches either
+// alternative; [!...] negates a class like [^...]. Braces without a comma are taken literally, so folder names
+// like {GUID} need no escaping. Names are matched case-insensitively on
+// Windows. Like filepath.Glob, wildcards match through directory symlinks,
+// but "**" doesn't descend into them, so link loops can't trap it.
+//
+// Unreadable directories are skipped. WalkGlob returns filepath.ErrBadPattern
+// for a malformed pattern before calling fn, and stops at the first error fn
+// returns, returning it.
+func WalkGlob(fsys FS, pattern string, fn func(path string) error) error {
+	patterns, err := expandBraces(pattern)
+	if err != nil {
+		return err
+	}
+
+	globs := make([][]string, len(patterns))
+	for i, pattern := range patterns {
+		if globs[i], err = splitGlob(pattern); err != nil {
+			return err
+		}
+	}
+
+	// alternatives may overlap, report each path once
+	emit := fn
+	if len(globs) > 1 {
+		seen := make(map[string]bool)
+		emit = func(path string) error {
+			if seen[path] {
+				return nil
+			}
+			seen[path] = true
+			return fn(path)
+		}
+	}
+
+	for _, segments := range globs {
+		if err := matchGlob(fsys, segments[0], segments[1:], emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GlobAll returns every path matching pattern, see WalkGlob
+func GlobAll(fsys FS, pattern string) ([]string, error) {
+	var matches []string
+	err := WalkGlob(fsys, pattern, func(path string) error {
+		matches = append(matches, path)
+		return nil
+	})
+	return matches, err
+}
+
+// splitGlob splits a pattern into the literal directory it starts from and
+// the segments below it, checking every segment's syntax
+func splitGlob(pattern string) ([]string, error) {
+	pattern = filepath.Clean(pattern)
+	volume := filepath.VolumeName(pattern)
+	parts := strings.Split(filepath.ToSlash(pattern[len(volume):]), "/")
+
+	start := volume
+	if parts[0] == "" {
+		// absolute path
+		start += string(filepath.Separator)
+		parts = parts[1:]
+	}
+
+	i := 0
+	for ; i < len(parts) && !HasMeta(parts[i]); i++ {
+		start = filepath.Join(start, parts[i])
+	}
+	if start == "" {
+		start = "."
+	}
+
+	segments := []string{start}
+	for _, part := range parts[i:] {
+		if part == globStar {
+			if segments[len(segments)-1] == globStar && len(segments) > 1 {
+				continue
+			}
+		} else if strings.Contains(part, globStar) {
+			return nil, filepath.ErrBadPattern
+		} else {
+			// shell style [!...] classes are filepath.Match's [^...]
+			part = strings.ReplaceAll(part, "[!", "[^")
+			if _, err := filepath.Match(part, ""); err != nil {
+				return nil, err
+			}
+		}
+		segments = append(segments, part)
+	}
+	return segments, nil
+}
+
+// matchGlob reports the paths below dir matching segments
+func matchGlob(fsys FS, dir string, segments []string, fn func(path string) error) error {
+	if len(segments) == 0 {
+		if _, err := fsys.Lstat(dir); err != nil {
+			return nil
+		}
+		return fn(dir)
+	}
+
+	segment, rest := segments[0], segments[1:]
+	if segment == globStar {
+		if err := matchGlob(fsys, dir, rest, fn); err != nil {
+			return err
+		}
+	} else if !HasMeta(segment) {
+		return matchGlob(fsys, filepath.Join(dir, segment), rest, fn)
+	}
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+
+		if segment == globStar {
+			// a trailing ** matches files too; directory links aren't followed
+			if !entry.IsDir() {
+				if len(rest) == 0 {
+					if err := fn(path); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := matchGlob(fsys, path, segments, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if matchName(segment, entry.Name()) {
+			if err := matchGlob(fsys, path, rest, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchName matches a single path segment, ignoring case on Windows
+func matchName(pattern string, name string) bool {
+	if runtime.GOOS == "windows" {
+		pattern = strings.ToLower(pattern)
+		name = strings.ToLower(name)
+	}
+	matched, _ := filepath.Match(pattern, name)
+	return matched
+}
+
+// expandBraces turns {a,b} alternatives into separate patterns. Groups
+// without a top-level comma stay literal.
+func expandBraces(pattern string) ([]string, error) {
+	start := -1
+	depth := 0
+	commas := []int(nil)
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			if depth == 0 {
+				start = i
+				commas = commas[:0]
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				return nil, filepath.ErrBadPattern
+			}
+			depth--
+			if depth > 0 || len(commas) == 0 {
+				continue
+			}
+
+			prefix, suffix := pattern[:start], pattern[i+1:]
+			bounds := append(append([]int{start}, commas...), i)
+			var patterns []string
+			for j := 0; j+1 < len(bounds); j++ {
+				expanded, err := expandBraces(prefix + pattern[bounds[j]+1:bounds[j+1]] + suffix)
+				if err != nil {
+					return nil, err
+				}
+				patterns = append(patterns, expanded...)
+			}
+			return patterns, nil
+		}
+	}
+
+	if depth != 0 {
+		return nil, filepath.ErrBadPattern
+	}
+	return []string{pattern}, nil
+}
+
+// errStopGlob ends a WalkGlob early from inside fn
+var errStopGlob = errors.New("stop glob")
+
+// GlobExists reports whether anything matches pattern
+func GlobExists(fsys FS, pattern string) bool {
+	err := WalkGlob(fsys, pattern, func(string) error { return errStopGlob })
+	return errors.Is(err, errStopGlob)
+}
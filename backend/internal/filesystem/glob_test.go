@@ -0,0 +1,81 @@
+package filesystem
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWalkGlob(t *testing.T) {
+	memory := NewMemory()
+	for _, name := range []string{
+		"p/one/cache2/a.tmp", "p/one/cache2/entries/b.tmp", "p/one/cache2/entries/c.dat",
+		"p/two/cache2/d.tmp", "p/two/other/e.tmp", "p/{GUID}/f.tmp", "p/x1.log", "p/x2.txt", "p/y3.log",
+	} {
+		memory.WriteFile(filepath.Join("/", filepath.FromSlash(name)), []byte(name))
+	}
+	memory.Symlink("/p/one", "/p/link")
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"/p/*/cache2/**/*.tmp", []string{"p/link/cache2/a.tmp", "p/link/cache2/entries/b.tmp", "p/one/cache2/a.tmp", "p/one/cache2/entries/b.tmp", "p/two/cache2/d.tmp"}},
+		{"/p/**/*.tmp", []string{"p/one/cache2/a.tmp", "p/one/cache2/entries/b.tmp", "p/two/cache2/d.tmp", "p/two/other/e.tmp", "p/{GUID}/f.tmp"}},
+		{"/p/one/cache2/**", []string{"p/one/cache2", "p/one/cache2/a.tmp", "p/one/cache2/entries", "p/one/cache2/entries/b.tmp", "p/one/cache2/entries/c.dat"}},
+		{"/p/**/**/d.tmp", []string{"p/two/cache2/d.tmp"}},
+		{"/p/*.{log,txt}", []string{"p/x1.log", "p/x2.txt", "p/y3.log"}},
+		{"/p/{x*,y*}.log", []string{"p/x1.log", "p/y3.log"}},
+		{"/p/[xz][0-9].*", []string{"p/x1.log", "p/x2.txt"}},
+		{"/p/[!x]?.log", []string{"p/y3.log"}},
+		{"/p/{GUID}/*", []string{"p/{GUID}/f.tmp"}},
+		{"/p/**/a.tmp", []string{"p/one/cache2/a.tmp"}},
+		{"/p/link/**/*.dat", []string{"p/link/cache2/entries/c.dat"}},
+		{"/p/link/*/*.tmp", []string{"p/link/cache2/a.tmp"}},
+		{"/missing/**", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			var want []string
+			for _, name := range test.want {
+				want = append(want, filepath.Join("/", filepath.FromSlash(name)))
+			}
+
+			got, err := GlobAll(memory, filepath.FromSlash(test.pattern))
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWalkGlobErrors(t *testing.T) {
+	memory := NewMemory()
+	memory.WriteFile("/a/b", nil)
+	memory.WriteFile("/a/c", nil)
+
+	for _, pattern := range []string{"/a/[", "/a/{b,c", "/a/b}", "/a/x**"} {
+		if _, err := GlobAll(memory, pattern); !errors.Is(err, filepath.ErrBadPattern) {
+			t.Errorf("%q: got %v, want ErrBadPattern", pattern, err)
+		}
+	}
+
+	calls := 0
+	stop := errors.New("stop")
+	err := WalkGlob(memory, "/a/*", func(string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("got %v after %d calls, want the callback's error after 1", err, calls)
+	}
+	if !GlobExists(memory, "/a/*") || GlobExists(memory, "/a/*.tmp") {
+		t.Error("GlobExists disagrees with the tree")
+	}
+}
@@ -0,0 +1,40 @@
+package filesystem
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// FileID identifies a file independently of the path it was reached through,
+// so hard links and files found by overlapping searches can be recognized
+type FileID struct {
+	Device uint64
+	Inode  uint64
+	// Path is set instead of Device and Inode when the file system doesn't
+	// expose file identities
+	Path string
+}
+
+// Identify returns the identity of the file at path described by info, which
+// must come from the current file system
+func Identify(path string, info fs.FileInfo) FileID {
+	if sys, ok := info.Sys().(memorySys); ok {
+		return FileID{Inode: sys.inode}
+	}
+	if device, inode, ok := systemFileID(path, info); ok {
+		return FileID{Device: device, Inode: inode}
+	}
+	return FileID{Path: filepath.Clean(path)}
+}
+
+// LinkCount returns how many hard links the file at path described by info
+// has, 1 when the file system doesn't tell
+func LinkCount(path string, info fs.FileInfo) uint64 {
+	if sys, ok := info.Sys().(memorySys); ok {
+		return max(sys.links, 1)
+	}
+	if links, ok := systemLinkCount(path, info); ok {
+		return max(links, 1)
+	}
+	return 1
+}
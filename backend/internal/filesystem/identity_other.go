@@ -0,0 +1,26 @@
+//go:build !windows
+
+package filesystem
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// systemFileID returns the device and inode numbers from lstat
+func systemFileID(_ string, info fs.FileInfo) (uint64, uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(stat.Dev), uint64(stat.Ino), true
+}
+
+// systemLinkCount returns the number of hard links from lstat
+func systemLinkCount(_ string, info fs.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Nlink), true
+}
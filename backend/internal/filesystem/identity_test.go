@@ -0,0 +1,112 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentifyHardLinks(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "original")
+	link := filepath.Join(dir, "link")
+	other := filepath.Join(dir, "other")
+
+	for _, name := range []string{original, other} {
+		if err := os.WriteFile(name, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Link(original, link); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	identify := func(name string) FileID {
+		info, err := OS{}.Lstat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Identify(name, info)
+	}
+
+	if identify(original) != identify(link) {
+		t.Error("hard links have different identities")
+	}
+	if identify(original) == identify(other) {
+		t.Error("different files share an identity")
+	}
+	if id := identify(original); id.Path != "" {
+		t.Errorf("fell back to the path: %+v", id)
+	}
+}
+
+func TestIdentifyMemoryLinks(t *testing.T) {
+	memory := NewMemory()
+	memory.WriteFile("/a", []byte("1"))
+	memory.WriteFile("/b", []byte("1"))
+	if err := memory.Link("/a", "/dir/c"); err != nil {
+		t.Fatal(err)
+	}
+
+	identify := func(name string) FileID {
+		info, err := memory.Lstat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Identify(name, info)
+	}
+
+	if identify("/a") != identify("/dir/c") || identify("/a") == identify("/b") {
+		t.Errorf("got /a %+v, /dir/c %+v, /b %+v", identify("/a"), identify("/dir/c"), identify("/b"))
+	}
+}
+
+func TestLinkCount(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "original")
+	if err := os.WriteFile(original, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(original, filepath.Join(dir, "link")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+	info, err := OS{}.Lstat(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := LinkCount(original, info); links != 2 {
+		t.Errorf("got %d links, want 2", links)
+	}
+}
+
+func TestLinkCountMemory(t *testing.T) {
+	memory := NewMemory()
+	memory.WriteFile("/a", []byte("1"))
+	if err := memory.Link("/a", "/b"); err != nil {
+		t.Fatal(err)
+	}
+	links := func(name string) uint64 {
+		info, err := memory.Lstat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return LinkCount(name, info)
+	}
+
+	if links("/a") != 2 || links("/b") != 2 {
+		t.Errorf("linked: /a %d, /b %d", links("/a"), links("/b"))
+	}
+	if err := memory.Remove("/a"); err != nil {
+		t.Fatal(err)
+	}
+	if links("/b") != 1 {
+		t.Errorf("after removing /a: %d", links("/b"))
+	}
+	memory.WriteFile("/c", []byte("1"))
+	if err := memory.Link("/c", "/b"); err != nil {
+		t.Fatal(err)
+	}
+	if links("/b") != 2 || links("/c") != 2 {
+		t.Errorf("after replacing /b: /b %d, /c %d", links("/b"), links("/c"))
+	}
+}
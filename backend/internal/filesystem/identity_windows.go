@@ -0,0 +1,54 @@
+package filesystem
+
+import (
+	"io/fs"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// systemFileID returns the volume serial number and file index. Windows
+// doesn't include them in os.Lstat results, so the file is opened without
+// read or write access to query them.
+func systemFileID(path string, info fs.FileInfo) (uint64, uint64, bool) {
+	data, ok := fileInformation(path, info)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(data.VolumeSerialNumber), uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow), true
+}
+
+// systemLinkCount returns the number of hard links, queried like systemFileID
+func systemLinkCount(path string, info fs.FileInfo) (uint64, bool) {
+	data, ok := fileInformation(path, info)
+	if !ok {
+		return 0, false
+	}
+	return uint64(data.NumberOfLinks), true
+}
+
+// fileInformation opens the file at path without read or write access and
+// returns what GetFileInformationByHandle reports about it
+func fileInformation(path string, info fs.FileInfo) (windows.ByHandleFileInformation, bool) {
+	var data windows.ByHandleFileInformation
+	if _, ok := info.Sys().(*syscall.Win32FileAttributeData); !ok {
+		return data, false
+	}
+
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return data, false
+	}
+	handle, err := windows.CreateFile(name, 0,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, nil,
+		windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS|windows.FILE_FLAG_OPEN_REPARSE_POINT, 0)
+	if err != nil {
+		return data, false
+	}
+	defer windows.CloseHandle(handle)
+
+	if err := windows.GetFileInformationByHandle(handle, &data); err != nil {
+		return data, false
+	}
+	return data, true
+}
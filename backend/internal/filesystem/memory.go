@@ -0,0 +1,467 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxSymlinkHops bounds symlink resolution, as the OS does, to catch loops
+const maxSymlinkHops = 40
+
+// Memory is an in-memory MutableFS for tests. Paths are cleaned with
+// filepath.Clean; parent directories are created as needed.
+type Memory struct {
+	mutex     sync.RWMutex
+	nodes     map[string]*memoryNode
+	children  map[string]map[string]bool // directory -> names of its entries
+	lastInode uint64
+	lastTemp  uint64
+	capacity  uint64 // bytes the files may hold, 0 if FreeSpace isn't supported
+}
+
+type memoryNode struct {
+	mode    fs.FileMode
+	data    []byte
+	modTime time.Time
+	target  string // symlink target
+	inode   uint64 // shared by hard links
+	links   uint64 // names the node is stored under
+	// ineffective is why overwriting the file wouldn't reach its data, see OverwriteIneffective
+	ineffective string
+}
+
+// memorySys is what Sys returns for Memory files
+type memorySys struct {
+	inode       uint64
+	links       uint64
+	ineffective string
+}
+
+// NewMemory returns an empty file system
+func NewMemory() *Memory {
+	return &Memory{nodes: make(map[string]*memoryNode), children: make(map[string]map[string]bool)}
+}
+
+// WriteFile creates or replaces a regular file
+func (m *Memory) WriteFile(name string, data []byte) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	name = filepath.Clean(name)
+	m.mkdirAll(filepath.Dir(name))
+	m.put(name, &memoryNode{mode: 0o644, data: append([]byte(nil), data...), modTime: time.Now()})
+}
+
+// MkdirAll creates a directory and its missing parents. It fails when the
+// closest existing ancestor is not a directory.
+func (m *Memory) MkdirAll(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	name = filepath.Clean(name)
+	for dir := name; ; {
+		if _, node, err := m.resolve(dir); err == nil {
+			if !node.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: errors.New("not a directory")}
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	m.mkdirAll(name)
+	return nil
+}
+
+// Symlink creates link pointing at target. Relative targets resolve from the link's directory.
+func (m *Memory) Symlink(target string, link string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	link = filepath.Clean(link)
+	m.mkdirAll(filepath.Dir(link))
+	m.put(link, &memoryNode{mode: fs.ModeSymlink | 0o777, target: target, modTime: time.Now()})
+}
+
+// Link creates a hard link to an existing file
+func (m *Memory) Link(existing string, link string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	node, ok := m.nodes[filepath.Clean(existing)]
+	if !ok {
+		return &fs.PathError{Op: "link", Path: existing, Err: fs.ErrNotExist}
+	}
+	if node.mode.IsDir() {
+		return &fs.PathError{Op: "link", Path: existing, Err: errors.New("is a directory")}
+	}
+
+	link = filepath.Clean(link)
+	m.mkdirAll(filepath.Dir(link))
+	m.put(link, node)
+	return nil
+}
+
+// Chtimes sets the modification time of a file or directory
+func (m *Memory) Chtimes(name string, modTime time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	node, ok := m.nodes[filepath.Clean(name)]
+	if !ok {
+		return &fs.PathError{Op: "chtimes", Path: name, Err: fs.ErrNotExist}
+	}
+	node.modTime = modTime
+	return nil
+}
+
+func (m *Memory) Stat(name string) (fs.FileInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	resolved, node, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return memoryFileInfo{name: filepath.Base(resolved), node: *node}, nil
+}
+
+func (m *Memory) Lstat(name string) (fs.FileInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	name = filepath.Clean(name)
+	_, node, err := m.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return memoryFileInfo{name: filepath.Base(name), node: *node}, nil
+}
+
+func (m *Memory) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	dir, node, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(m.children[dir]))
+	for childName := range m.children[dir] {
+		child := m.nodes[filepath.Join(dir, childName)]
+		entries = append(entries, fs.FileInfoToDirEntry(memoryFileInfo{name: childName, node: *child}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *Memory) ReadFile(name string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	_, node, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+// Open returns a reader over a copy of the file's data
+func (m *Memory) Open(name string) (io.ReadCloser, error) {
+	data, err := m.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *Memory) Remove(name string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	name = filepath.Clean(name)
+	node, ok := m.nodes[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if node.mode.IsDir() && len(m.children[name]) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+
+	node.links--
+	delete(m.nodes, name)
+	delete(m.children, name)
+	if parent := filepath.Dir(name); parent != name {
+		delete(m.children[parent], filepath.Base(name))
+	}
+	return nil
+}
+
+func (m *Memory) Truncate(name string, size int64) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, node, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return &fs.PathError{Op: "truncate", Path: name, Err: err}
+	}
+	if !node.mode.IsRegular() {
+		return &fs.PathError{Op: "truncate", Path: name, Err: errors.New("not a regular file")}
+	}
+
+	if int64(len(node.data)) >= size {
+		node.data = node.data[:size]
+	} else {
+		node.data = append(node.data, make([]byte, size-int64(len(node.data)))...)
+	}
+	node.modTime = time.Now()
+	return nil
+}
+
+// Overwrite fills the file's data in place; hard links see the new contents
+func (m *Memory) Overwrite(name string, fill func(block []byte)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, node, err := m.resolve(filepath.Clean(name))
+	if err != nil {
+		return &fs.PathError{Op: "overwrite", Path: name, Err: err}
+	}
+	if !node.mode.IsRegular() {
+		return &fs.PathError{Op: "overwrite", Path: name, Err: errors.New("not a regular file")}
+	}
+
+	fill(node.data)
+	node.modTime = time.Now()
+	return nil
+}
+
+// SetOverwriteIneffective makes OverwriteIneffective report reason for the
+// file, as for a file on a copy-on-write file system
+func (m *Memory) SetOverwriteIneffective(name string, reason string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	node, ok := m.nodes[filepath.Clean(name)]
+	if !ok {
+		return &fs.PathError{Op: "chattr", Path: name, Err: fs.ErrNotExist}
+	}
+	node.ineffective = reason
+	return nil
+}
+
+// SetCapacity makes the file system hold at most capacity bytes of file data.
+// Files written by CreateTemp fail with ENOSPC when it is full; FreeSpace
+// reports what is left.
+func (m *Memory) SetCapacity(capacity uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.capacity = capacity
+}
+
+func (m *Memory) FreeSpace(name string) (uint64, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if _, _, err := m.resolve(filepath.Clean(name)); err != nil {
+		return 0, &fs.PathError{Op: "statfs", Path: name, Err: err}
+	}
+	if m.capacity == 0 {
+		return 0, &fs.PathError{Op: "statfs", Path: name, Err: errors.ErrUnsupported}
+	}
+	return m.capacity - min(m.used(), m.capacity), nil
+}
+
+// used sums the data of every file, hard links counting once
+func (m *Memory) used() uint64 {
+	seen := make(map[*memoryNode]bool)
+	var used uint64
+	for _, node := range m.nodes {
+		if !seen[node] {
+			seen[node] = true
+			used += uint64(len(node.data))
+		}
+	}
+	return used
+}
+
+// CreateTemp creates an empty file in dir, replacing the last "*" in pattern
+// with a number, or appending it
+func (m *Memory) CreateTemp(dir string, pattern string) (WritableFile, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, parent, err := m.resolve(filepath.Clean(dir))
+	if err == nil && !parent.mode.IsDir() {
+		err = errors.New("not a directory")
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "createtemp", Path: dir, Err: err}
+	}
+
+	m.lastTemp++
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	name := filepath.Join(filepath.Clean(dir), prefix+strconv.FormatUint(m.lastTemp, 10)+suffix)
+
+	node := &memoryNode{mode: 0o600, modTime: time.Now()}
+	m.put(name, node)
+	return &memoryFile{memory: m, name: name, node: node}, nil
+}
+
+// memoryFile is a file being written by CreateTemp
+type memoryFile struct {
+	memory *Memory
+	name   string
+	node   *memoryNode
+}
+
+func (f *memoryFile) Name() string { return f.name }
+func (f *memoryFile) Sync() error  { return nil }
+func (f *memoryFile) Close() error { return nil }
+
+// Write appends p, as much of it as the capacity allows
+func (f *memoryFile) Write(p []byte) (int, error) {
+	m := f.memory
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	n := len(p)
+	if m.capacity > 0 {
+		n = int(min(uint64(n), m.capacity-min(m.used(), m.capacity)))
+	}
+	f.node.data = append(f.node.data, p[:n]...)
+	f.node.modTime = time.Now()
+	if n < len(p) {
+		return n, &fs.PathError{Op: "write", Path: f.name, Err: syscall.ENOSPC}
+	}
+	return n, nil
+}
+
+func (m *Memory) mkdirAll(name string) {
+	for {
+		if _, ok := m.nodes[name]; ok {
+			return
+		}
+		m.put(name, &memoryNode{mode: fs.ModeDir | 0o755, modTime: time.Now()})
+
+		parent := filepath.Dir(name)
+		if parent == name {
+			return
+		}
+		name = parent
+	}
+}
+
+// put stores a node and registers it with its parent directory
+func (m *Memory) put(name string, node *memoryNode) {
+	if node.inode == 0 {
+		m.lastInode++
+		node.inode = m.lastInode
+	}
+	if replaced, ok := m.nodes[name]; ok {
+		replaced.links--
+	}
+	node.links++
+	m.nodes[name] = node
+	if parent := filepath.Dir(name); parent != name {
+		if m.children[parent] == nil {
+			m.children[parent] = make(map[string]bool)
+		}
+		m.children[parent][filepath.Base(name)] = true
+	}
+}
+
+// resolve follows symlinks in name until it reaches an existing non-link node
+func (m *Memory) resolve(name string) (string, *memoryNode, error) {
+	for hops := 0; hops < maxSymlinkHops; hops++ {
+		resolved, node, err := m.lookup(name)
+		if err != nil {
+			return "", nil, err
+		}
+		if node.mode&fs.ModeSymlink == 0 {
+			return resolved, node, nil
+		}
+		name = linkTarget(resolved, node)
+	}
+	return "", nil, errors.New("too many levels of symbolic links")
+}
+
+// lookup finds the node of name, following symlinks in its parent
+// directories but not a final one, and returns the name it was found under
+func (m *Memory) lookup(name string) (string, *memoryNode, error) {
+	for hops := 0; hops < maxSymlinkHops; hops++ {
+		if node, ok := m.nodes[name]; ok {
+			return name, node, nil
+		}
+
+		// look for a directory link further up the path
+		rest := filepath.Base(name)
+		dir := filepath.Dir(name)
+		for {
+			node, ok := m.nodes[dir]
+			if ok && node.mode&fs.ModeSymlink == 0 {
+				return "", nil, fs.ErrNotExist
+			}
+			if ok {
+				name = filepath.Join(linkTarget(dir, node), rest)
+				break
+			}
+			if parent := filepath.Dir(dir); parent != dir {
+				rest = filepath.Join(filepath.Base(dir), rest)
+				dir = parent
+				continue
+			}
+			return "", nil, fs.ErrNotExist
+		}
+	}
+	return "", nil, errors.New("too many levels of symbolic links")
+}
+
+// linkTarget returns the cleaned target of the link at name. Relative
+// targets resolve from the link's directory.
+func linkTarget(name string, link *memoryNode) string {
+	target := link.target
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(name), target)
+	}
+	return filepath.Clean(target)
+}
+
+// memoryFileInfo describes a Memory node
+type memoryFileInfo struct {
+	name string
+	node memoryNode
+}
+
+func (info memoryFileInfo) Name() string       { return info.name }
+func (info memoryFileInfo) Size() int64        { return int64(len(info.node.data)) }
+func (info memoryFileInfo) Mode() fs.FileMode  { return info.node.mode }
+func (info memoryFileInfo) ModTime() time.Time { return info.node.modTime }
+func (info memoryFileInfo) IsDir() bool        { return info.node.mode.IsDir() }
+func (info memoryFileInfo) Sys() any {
+	return memorySys{inode: info.node.inode, links: info.node.links, ineffective: info.node.ineffective}
+}
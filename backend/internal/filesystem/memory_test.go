@@ -0,0 +1,136 @@
+package filesystem
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// buildBoth creates the same tree on disk and in memory
+func buildBoth(t *testing.T) (string, *Memory) {
+	t.Helper()
+
+	dir := t.TempDir()
+	memory := NewMemory()
+	for _, name := range []string{"a/1.log", "a/2.tmp", "a/sub/3.log", "b/4.log", "c.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		memory.WriteFile(path, []byte(name))
+	}
+	return dir, memory
+}
+
+func walk(t *testing.T, fsys FS, root string, skip string) []string {
+	t.Helper()
+
+	var visited []string
+	err := WalkDir(fsys, root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == skip {
+			return fs.SkipDir
+		}
+		visited = append(visited, strings.TrimPrefix(path, root))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return visited
+}
+
+func TestMemoryMatchesOS(t *testing.T) {
+	dir, memory := buildBoth(t)
+
+	for _, skip := range []string{"", "a"} {
+		onDisk, inMemory := walk(t, OS{}, dir, skip), walk(t, memory, dir, skip)
+		if !slices.Equal(onDisk, inMemory) {
+			t.Errorf("WalkDir skipping %q: disk %v, memory %v", skip, onDisk, inMemory)
+		}
+	}
+
+	for _, pattern := range []string{"*", "*/*.log", "a/*", "?/sub/*", "missing/*", "c.txt"} {
+		want, _ := filepath.Glob(filepath.Join(dir, pattern))
+		onDisk, _ := GlobAll(OS{}, filepath.Join(dir, pattern))
+		inMemory, _ := GlobAll(memory, filepath.Join(dir, pattern))
+		if !slices.Equal(onDisk, want) || !slices.Equal(inMemory, want) {
+			t.Errorf("GlobAll(%q): disk %v, memory %v, want %v", pattern, onDisk, inMemory, want)
+		}
+	}
+
+	for _, name := range []string{"c.txt", "a", "missing"} {
+		path := filepath.Join(dir, name)
+		onDisk, diskErr := readOpened(OS{}, path)
+		inMemory, memoryErr := readOpened(memory, path)
+		if onDisk != inMemory || (diskErr == nil) != (memoryErr == nil) {
+			t.Errorf("Open(%q): disk %q, %v; memory %q, %v", name, onDisk, diskErr, inMemory, memoryErr)
+		}
+	}
+}
+
+// readOpened reads a file through Open
+func readOpened(fsys FS, name string) (string, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	return string(data), err
+}
+
+func TestMemoryMutations(t *testing.T) {
+	memory := NewMemory()
+	root := filepath.Join(string(filepath.Separator), "root")
+	file := filepath.Join(root, "dir", "file")
+	memory.WriteFile(file, []byte("12345"))
+	memory.Symlink(file, filepath.Join(root, "link"))
+
+	if info, err := memory.Stat(filepath.Join(root, "link")); err != nil || info.Size() != 5 {
+		t.Errorf("Stat through link: %v, %v", info, err)
+	}
+	if info, err := memory.Lstat(filepath.Join(root, "link")); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Lstat of link: %v, %v", info, err)
+	}
+
+	if err := memory.Truncate(file, 2); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := memory.ReadFile(file); string(data) != "12" {
+		t.Errorf("truncated content %q", data)
+	}
+
+	if err := memory.MkdirAll(filepath.Join(root, "link", "sub")); err == nil {
+		t.Error("created a directory beneath a file")
+	}
+	if err := memory.MkdirAll(filepath.Join(root, "dir", "sub")); err != nil {
+		t.Errorf("MkdirAll: %v", err)
+	}
+	if err := memory.Remove(filepath.Join(root, "dir", "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := memory.Remove(filepath.Join(root, "dir")); err == nil {
+		t.Error("removed a non-empty directory")
+	}
+	if err := memory.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.Remove(filepath.Join(root, "dir")); err != nil {
+		t.Errorf("remove empty directory: %v", err)
+	}
+	if _, err := memory.Stat(filepath.Join(root, "link")); err == nil {
+		t.Error("dangling link still resolves")
+	}
+}
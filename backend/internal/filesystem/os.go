@@ -0,0 +1,73 @@
+package filesystem
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// overwriteBlockSize is how much Overwrite writes at a time
+const overwriteBlockSize = 1 << 20
+
+// OS is the real file system
+type OS struct{}
+
+func (OS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+func (OS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+func (OS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+func (OS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (OS) Open(name string) (io.ReadCloser, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (OS) Remove(name string) error { return os.Remove(name) }
+
+func (OS) Truncate(name string, size int64) error { return os.Truncate(name, size) }
+
+func (OS) Overwrite(name string, fill func(block []byte)) error {
+	file, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = &fs.PathError{Op: "overwrite", Path: name, Err: errors.New("not a regular file")}
+	}
+
+	block := make([]byte, overwriteBlockSize)
+	for offset := int64(0); err == nil && offset < info.Size(); offset += int64(len(block)) {
+		chunk := block[:min(int64(len(block)), info.Size()-offset)]
+		fill(chunk)
+		_, err = file.WriteAt(chunk, offset)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (OS) MkdirAll(name string) error { return os.MkdirAll(name, 0o755) }
+
+func (OS) CreateTemp(dir string, pattern string) (WritableFile, error) {
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (OS) FreeSpace(name string) (uint64, error) { return freeSpace(name) }
@@ -0,0 +1,17 @@
+package filesystem
+
+import "io/fs"
+
+// OverwriteIneffective tells why overwriting the file at path in place
+// wouldn't reach the blocks holding its data, or returns "" if nothing
+// suggests so. Copy-on-write and log-structured file systems write new data
+// elsewhere, and compressed files change their layout when rewritten.
+//
+// Only what the file system reports can be detected: wear levelling on SSDs
+// and flash drives, snapshots and backups are invisible from here.
+func OverwriteIneffective(path string, info fs.FileInfo) string {
+	if sys, ok := info.Sys().(memorySys); ok {
+		return sys.ineffective
+	}
+	return systemOverwriteIneffective(path, info)
+}
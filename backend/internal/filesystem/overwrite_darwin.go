@@ -0,0 +1,20 @@
+package filesystem
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// systemOverwriteIneffective reports APFS, which is copy-on-write
+func systemOverwriteIneffective(path string, _ fs.FileInfo) string {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return ""
+	}
+
+	if name := unix.ByteSliceToString(stat.Fstypename[:]); name == "apfs" {
+		return "copy-on-write file system (" + name + ")"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+// inode flags from linux/fs.h
+const (
+	compressedFlag = 0x00000004 // FS_COMPR_FL
+	noCOWFlag      = 0x00800000 // FS_NOCOW_FL
+)
+
+// copyOnWriteFS names the file systems that never overwrite data in place,
+// by statfs magic number
+var copyOnWriteFS = map[int64]string{
+	unix.BTRFS_SUPER_MAGIC: "btrfs",
+	0x2fc12fc1:             "zfs",
+	0xca451a4e:             "bcachefs",
+	unix.F2FS_SUPER_MAGIC:  "f2fs",
+	unix.NILFS_SUPER_MAGIC: "nilfs2",
+}
+
+// systemOverwriteIneffective checks the file system type and the file's
+// attributes. Files marked No_COW (chattr +C) on btrfs are rewritten in place.
+func systemOverwriteIneffective(path string, _ fs.FileInfo) string {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return ""
+	}
+
+	flags := fileFlags(path)
+	if name, ok := copyOnWriteFS[int64(stat.Type)]; ok {
+		if name == "btrfs" && flags&noCOWFlag != 0 {
+			return ""
+		}
+		return "copy-on-write file system (" + name + ")"
+	}
+	if flags&compressedFlag != 0 {
+		return "compressed file"
+	}
+	return ""
+}
+
+// fileFlags returns the inode flags lsattr shows, 0 if they can't be read
+func fileFlags(path string) uint32 {
+	fd, err := unix.Open(path, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return 0
+	}
+	defer unix.Close(fd)
+
+	flags, err := unix.IoctlGetUint32(fd, unix.FS_IOC_GETFLAGS)
+	if err != nil {
+		return 0
+	}
+	return flags
+}
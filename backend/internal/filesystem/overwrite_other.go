@@ -0,0 +1,10 @@
+//go:build !windows && !linux && !darwin
+
+package filesystem
+
+import "io/fs"
+
+// systemOverwriteIneffective can't tell anything on this platform
+func systemOverwriteIneffective(string, fs.FileInfo) string {
+	return ""
+}
@@ -0,0 +1,69 @@
+package filesystem
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverwriteOS(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "secret")
+	data := bytes.Repeat([]byte("secret"), overwriteBlockSize/3)
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := 0
+	err := OS{}.Overwrite(name, func(block []byte) {
+		blocks++
+		for i := range block {
+			block[i] = 'x'
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, bytes.Repeat([]byte("x"), len(data))) {
+		t.Errorf("file not overwritten, %d bytes", len(got))
+	}
+	if blocks != 2 {
+		t.Errorf("got %d blocks, want 2", blocks)
+	}
+
+	if err := (OS{}).Overwrite(filepath.Dir(name), func([]byte) {}); err == nil {
+		t.Error("overwrote a directory")
+	}
+}
+
+func TestOverwriteMemory(t *testing.T) {
+	memory := NewMemory()
+	memory.WriteFile("/secret", []byte("secret"))
+	if err := memory.Link("/secret", "/link"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := memory.Overwrite("/secret", func(block []byte) { clear(block) }); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := memory.ReadFile("/link"); !bytes.Equal(data, make([]byte, 6)) {
+		t.Errorf("hard link reads %q", data)
+	}
+
+	info, _ := memory.Lstat("/secret")
+	if reason := OverwriteIneffective("/secret", info); reason != "" {
+		t.Errorf("got %q for a plain file", reason)
+	}
+	if err := memory.SetOverwriteIneffective("/secret", "copy-on-write file system (test)"); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = memory.Lstat("/secret")
+	if reason := OverwriteIneffective("/secret", info); reason != "copy-on-write file system (test)" {
+		t.Errorf("got %q", reason)
+	}
+}
@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// volumeFileSystems caches the file system name per volume root
+var volumeFileSystems sync.Map
+
+// systemOverwriteIneffective reports NTFS-compressed files and files on ReFS,
+// which writes changed blocks elsewhere
+func systemOverwriteIneffective(path string, info fs.FileInfo) string {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok &&
+		data.FileAttributes&windows.FILE_ATTRIBUTE_COMPRESSED != 0 {
+		return "compressed file"
+	}
+
+	if name := volumeFileSystem(path); strings.EqualFold(name, "ReFS") {
+		return "copy-on-write file system (" + name + ")"
+	}
+	return ""
+}
+
+// volumeFileSystem asks the volume holding path for its file system name
+func volumeFileSystem(path string) string {
+	root := filepath.VolumeName(path)
+	if root == "" {
+		return ""
+	}
+	root = strings.TrimSuffix(root, `\`) + `\`
+	if name, ok := volumeFileSystems.Load(root); ok {
+		return name.(string)
+	}
+
+	var name string
+	if rootName, err := windows.UTF16PtrFromString(root); err == nil {
+		buffer := make([]uint16, windows.MAX_PATH+1)
+		if windows.GetVolumeInformation(rootName, nil, 0, nil, nil, nil, &buffer[0], uint32(len(buffer))) == nil {
+			name = windows.UTF16ToString(buffer)
+		}
+	}
+
+	volumeFileSystems.Store(root, name)
+	return name
+}
@@ -0,0 +1,260 @@
+// Package i18n provides localization of cleaner definitions and server messages.
+//
+// Locales are loaded from one file per locale in the locales directory
+// (e.g. resources/locales/uk.json), holding translated server messages and
+// cleaner strings keyed by cleaner ID. Cleaner definitions may also carry
+// their own inline translations; locale files take precedence over them.
+// A locale is only offered to clients when it has a file, which may hold just
+// messages. English is the built-in default and needs no file.
+package i18n
+
+import (
+	"backend/internal/models"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+)
+
+// DefaultLocale is used when no requested locale is available
+const DefaultLocale = "en"
+
+// Locale is the content of a single locale file
+type Locale struct {
+	Messages map[string]string                    `json:"messages,omitempty"`
+	Cleaners map[string]models.CleanerTranslation `json:"cleaners,omitempty"` // keyed by cleaner ID
+}
+
+var (
+	mutex   sync.RWMutex
+	locales = map[string]Locale{}
+)
+
+// LoadLocales reads every .json/.yaml/.yml file in dir as a locale named
+// after the file (uk.json -> "uk") and makes them the active catalog.
+// A missing directory leaves only the built-in English messages.
+func LoadLocales(dir string) error {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]Locale)
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		filePath := filepath.Join(dir, file.Name())
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			slog.Error("Error reading locale", "file", filePath, "error", err)
+			continue
+		}
+
+		if ext != ".json" {
+			if data, err = yaml.YAMLToJSON(data); err != nil {
+				slog.Error("Error parsing locale", "file", filePath, "error", err)
+				continue
+			}
+		}
+
+		var locale Locale
+		if err := json.Unmarshal(data, &locale); err != nil {
+			slog.Error("Error parsing locale", "file", filePath, "error", err)
+			continue
+		}
+
+		name := normalizeTag(strings.TrimSuffix(file.Name(), filepath.Ext(file.Name())))
+		loaded[name] = locale
+		slog.Info("Loaded locale", "locale", name, "file", file.Name())
+	}
+
+	mutex.Lock()
+	locales = loaded
+	mutex.Unlock()
+	return nil
+}
+
+// ResolveLocale picks the locale for a request: an explicit ?lang= value first,
+// then the Accept-Language entries by descending quality. Region subtags fall
+// back to their base language ("uk-UA" -> "uk"). Returns DefaultLocale when
+// nothing requested is available.
+func ResolveLocale(lang string, acceptLanguage string) string {
+	candidates := parseAcceptLanguage(acceptLanguage)
+	if lang != "" {
+		candidates = append([]string{lang}, candidates...)
+	}
+
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	for _, candidate := range candidates {
+		tag := normalizeTag(candidate)
+		base, _, _ := strings.Cut(tag, "-")
+
+		for _, option := range []string{tag, base} {
+			if option == DefaultLocale {
+				return DefaultLocale
+			}
+			if _, ok := locales[option]; ok {
+				return option
+			}
+		}
+	}
+
+	return DefaultLocale
+}
+
+// Message returns the text of a message ID in the given locale, falling back
+// to English, formatted with args. Arguments that are models.Message (or
+// lists of them, joined with "; ") are rendered in the same locale first.
+func Message(locale string, id string, args ...any) string {
+	args = renderArgs(locale, args)
+
+	mutex.RLock()
+	text, ok := locales[locale].Messages[id]
+	mutex.RUnlock()
+
+	if !ok {
+		text, ok = defaultMessages[id]
+		if !ok {
+			text = id
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// renderArgs returns a copy of args with nested messages rendered in locale,
+// leaving the caller's arguments untouched
+func renderArgs(locale string, args []any) []any {
+	rendered := slices.Clone(args)
+	for i, arg := range rendered {
+		switch arg := arg.(type) {
+		case models.Message:
+			rendered[i] = Message(locale, arg.ID, arg.Args...)
+		case []models.Message:
+			texts := make([]string, len(arg))
+			for j, message := range arg {
+				texts[j] = Message(locale, message.ID, message.Args...)
+			}
+			rendered[i] = strings.Join(texts, "; ")
+		}
+	}
+	return rendered
+}
+
+// LocalizeCleaners returns copies of the cleaners with names, labels,
+// descriptions and warnings in the given locale. Strings without a
+// translation keep their English value. Translations are cleared in the result.
+// Reasons and detection rules carrying a message ID are rendered in the locale too.
+func LocalizeCleaners(cleaners []models.Cleaner, locale string) []models.Cleaner {
+	mutex.RLock()
+	fileTranslations := locales[locale].Cleaners
+	mutex.RUnlock()
+
+	localized := make([]models.Cleaner, len(cleaners))
+	for i, cleaner := range cleaners {
+		inline := cleaner.Translations[locale]
+		fromFile := fileTranslations[cleaner.ID]
+
+		cleaner.Name = firstNonEmpty(fromFile.Name, inline.Name, cleaner.Name)
+		cleaner.Description = firstNonEmpty(fromFile.Description, inline.Description, cleaner.Description)
+		if cleaner.ReasonID != "" {
+			cleaner.Reason = Message(locale, cleaner.ReasonID, cleaner.ReasonArgs...)
+		}
+		if cleaner.Detected != nil && cleaner.Detected.RuleID != "" {
+			detected := *cleaner.Detected
+			detected.Rule = Message(locale, detected.RuleID, detected.RuleArgs...)
+			cleaner.Detected = &detected
+		}
+
+		options := make([]models.Option, len(cleaner.Options))
+		for j, option := range cleaner.Options {
+			inlineOption := inline.Options[option.ID]
+			fileOption := fromFile.Options[option.ID]
+
+			option.Label = firstNonEmpty(fileOption.Label, inlineOption.Label, option.Label)
+			option.Description = firstNonEmpty(fileOption.Description, inlineOption.Description, option.Description)
+			option.Warning = firstNonEmpty(fileOption.Warning, inlineOption.Warning, option.Warning)
+			if option.ReasonID != "" {
+				option.Reason = Message(locale, option.ReasonID, option.ReasonArgs...)
+			}
+			options[j] = option
+		}
+		cleaner.Options = options
+		cleaner.Translations = nil
+
+		localized[i] = cleaner
+	}
+
+	return localized
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header,
+// highest quality first. Entries with q=0 and the "*" wildcard are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		entries = append(entries, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].quality > entries[j].quality })
+
+	tags := make([]string, len(entries))
+	for i, entry := range entries {
+		tags[i] = entry.tag
+	}
+	return tags
+}
+
+// normalizeTag lower-cases a language tag and uses "-" as the subtag separator
+func normalizeTag(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package i18n
+
+import (
+	"backend/internal/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// useLocales loads the given locale files for the test and restores the
+// previous catalog afterwards
+func useLocales(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	mutex.RLock()
+	previous := locales
+	mutex.RUnlock()
+	t.Cleanup(func() {
+		mutex.Lock()
+		locales = previous
+		mutex.Unlock()
+	})
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := LoadLocales(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"uk", []string{"uk"}},
+		{"de;q=0.5, uk-UA, en;q=0.8", []string{"uk-UA", "en", "de"}},
+		{"fr;q=0.9, de;q=0.9", []string{"fr", "de"}},
+		{"uk;q=0, *;q=0.5, pl", []string{"pl"}},
+		{"uk;q=oops, de;q=0.1", []string{"uk", "de"}},
+	}
+
+	for _, test := range tests {
+		if got := parseAcceptLanguage(test.header); !slices.Equal(got, test.want) {
+			t.Errorf("parseAcceptLanguage(%q) = %q, want %q", test.header, got, test.want)
+		}
+	}
+}
+
+func TestResolveLocale(t *testing.T) {
+	useLocales(t, map[string]string{
+		"uk.json":    `{"messages": {}}`,
+		"pt_BR.yaml": "messages: {}\n",
+	})
+
+	tests := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		want           string
+	}{
+		{"nothing requested", "", "", DefaultLocale},
+		{"exact match", "", "uk", "uk"},
+		{"region falls back to the base language", "", "uk-UA,en;q=0.5", "uk"},
+		{"region locale file", "", "pt-BR", "pt-br"},
+		{"underscore and case", "", "PT_br", "pt-br"},
+		{"highest quality wins", "", "en;q=0.4, uk;q=0.9", "uk"},
+		{"unavailable entries are skipped", "", "fr, de;q=0.9, uk;q=0.2", "uk"},
+		{"english before a lower-quality locale", "", "en, uk;q=0.5", DefaultLocale},
+		{"lang takes precedence", "uk", "en", "uk"},
+		{"lang region falls back", "uk-UA", "en", "uk"},
+		{"unknown lang falls back to the header", "fr", "uk", "uk"},
+		{"unknown locale falls back to english", "fr", "de-DE, es;q=0.5", DefaultLocale},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ResolveLocale(test.lang, test.acceptLanguage); got != test.want {
+				t.Errorf("ResolveLocale(%q, %q) = %q, want %q", test.lang, test.acceptLanguage, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	useLocales(t, map[string]string{
+		"uk.json": `{"messages": {"rule.any": "будь-яке: %s", "rule.dir": "тека існує: %s"}}`,
+	})
+
+	nested := models.Message{ID: MsgRuleDir, Args: []any{"/opt/app"}}
+	args := []any{nested}
+
+	tests := []struct {
+		locale string
+		id     string
+		args   []any
+		want   string
+	}{
+		{"uk", MsgRuleAny, args, "будь-яке: тека існує: /opt/app"},
+		{"en", MsgRuleAny, args, "any: dir exists: /opt/app"},
+		{"uk", MsgRuleFile, []any{"/a"}, "file exists: /a"},
+		{"uk", MsgRuleAll, []any{[]models.Message{nested, {ID: MsgRuleAlways}}}, "all: [тека існує: /opt/app; always]"},
+		{"uk", "unknown.id", nil, "unknown.id"},
+	}
+
+	for _, test := range tests {
+		if got := Message(test.locale, test.id, test.args...); got != test.want {
+			t.Errorf("Message(%q, %q) = %q, want %q", test.locale, test.id, got, test.want)
+		}
+	}
+	if !reflect.DeepEqual(args, []any{nested}) {
+		t.Error("Message changed the caller's arguments")
+	}
+}
+
+func TestLocalizeCleaners(t *testing.T) {
+	useLocales(t, map[string]string{
+		"uk.json": `{
+			"messages": {"reason.not_detected": "не виявлено", "reason.no_actions": "немає дій для %s", "rule.dir": "тека існує: %s"},
+			"cleaners": {"app": {"name": "Програма", "options": {"cache": {"label": "Кеш"}}}}
+		}`,
+	})
+
+	detected := &models.DetectionResult{Rule: "dir exists: /opt/app", RuleID: MsgRuleDir, RuleArgs: []any{"/opt/app"}}
+	cleaners := []models.Cleaner{{
+		ID: "app", Name: "App", Description: "Cleans the app",
+		Reason: "not detected on this machine", ReasonID: MsgReasonNotDetected,
+		Detected: detected,
+		Options: []models.Option{
+			{ID: "cache", Label: "Cache", Reason: "no actions for linux/amd64", ReasonID: MsgReasonNoActions, ReasonArgs: []any{"linux/amd64"}},
+			{ID: "logs", Label: "Logs", Warning: "Slow"},
+		},
+		Translations: map[string]models.CleanerTranslation{"uk": {Description: "Очищує програму"}},
+	}}
+
+	localized := LocalizeCleaners(cleaners, "uk")[0]
+	if localized.Name != "Програма" || localized.Description != "Очищує програму" {
+		t.Errorf("got name %q and description %q", localized.Name, localized.Description)
+	}
+	if localized.Reason != "не виявлено" || localized.Detected.Rule != "тека існує: /opt/app" {
+		t.Errorf("got reason %q and rule %q", localized.Reason, localized.Detected.Rule)
+	}
+	if option := localized.Options[0]; option.Label != "Кеш" || option.Reason != "немає дій для linux/amd64" {
+		t.Errorf("got option label %q and reason %q", option.Label, option.Reason)
+	}
+	if option := localized.Options[1]; option.Label != "Logs" || option.Warning != "Slow" || option.Reason != "" {
+		t.Errorf("untranslated option changed: %+v", option)
+	}
+	if localized.Translations != nil {
+		t.Error("translations not cleared")
+	}
+
+	if detected.Rule != "dir exists: /opt/app" || cleaners[0].Name != "App" {
+		t.Error("the input cleaners were changed")
+	}
+	if english := LocalizeCleaners(cleaners, DefaultLocale)[0]; english.Reason != "not detected on this machine" {
+		t.Errorf("got english reason %q", english.Reason)
+	}
+}
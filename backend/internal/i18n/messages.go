@@ -0,0 +1,98 @@
+package i18n
+
+// Message IDs returned by the HTTP handlers. Clients can switch on the ID
+// while showing the localized text next to it.
+const (
+	MsgInvalidJSON        = "error.invalid_json"
+	MsgRequestTimeout     = "error.request_timeout"
+	MsgLoadCleaners       = "error.load_cleaners"
+	MsgFilterCleaners     = "error.filter_cleaners"
+	MsgProcessRequests    = "error.process_requests"
+	MsgRegistryBackup     = "error.registry_backup"
+	MsgUnknownIDs         = "error.unknown_ids"
+	MsgInvalidSizeBasis   = "error.invalid_size_basis"
+	MsgInvalidWipe        = "error.invalid_wipe"
+	MsgWipeRunning        = "error.wipe_running"
+	MsgWipeMargin         = "error.wipe_margin"
+	MsgWipeFailed         = "error.wipe_failed"
+	MsgReviewCancelled    = "message.review_cancelled"
+	MsgCleanCancelled     = "message.clean_cancelled"
+	MsgWipeCancelled      = "message.wipe_cancelled"
+	MsgOperationCancelled = "message.operation_cancelled"
+	MsgNoOperation        = "message.no_operation"
+)
+
+// Message IDs of the reasons a cleaner or option doesn't apply, reported by
+// GET /api/cleaners
+const (
+	MsgReasonNoOptions          = "reason.no_options"
+	MsgReasonNotDetected        = "reason.not_detected"
+	MsgReasonCleanerUnavailable = "reason.cleaner_unavailable"
+	MsgReasonOptionUnavailable  = "reason.option_unavailable"
+	MsgReasonNoActions          = "reason.no_actions"
+)
+
+// Message IDs describing the detection rule that decided whether a cleaner is installed
+const (
+	MsgRuleAlways          = "rule.always"
+	MsgRuleAll             = "rule.all"
+	MsgRuleAny             = "rule.any"
+	MsgRuleNot             = "rule.not"
+	MsgRuleNotNone         = "rule.not_none"
+	MsgRuleExecutable      = "rule.executable"
+	MsgRuleFile            = "rule.file"
+	MsgRuleDir             = "rule.dir"
+	MsgRulePath            = "rule.path"
+	MsgRuleContains        = "rule.contains"
+	MsgRuleINIKey          = "rule.ini_key"
+	MsgRuleJSONKey         = "rule.json_key"
+	MsgRuleVersion         = "rule.version"
+	MsgRuleRegistryKey     = "rule.registry_key"
+	MsgRuleRegistryValue   = "rule.registry_value"
+	MsgRuleRegistryPattern = "rule.registry_pattern"
+)
+
+// defaultMessages are the English texts, used when a locale doesn't translate a message.
+// Messages may contain fmt verbs for the arguments passed to Message.
+var defaultMessages = map[string]string{
+	MsgInvalidJSON:        "Invalid JSON",
+	MsgRequestTimeout:     "Request timed out",
+	MsgLoadCleaners:       "Error loading cleaners: %v",
+	MsgFilterCleaners:     "Error filtering installed cleaners: %v",
+	MsgProcessRequests:    "Error processing requests: %v",
+	MsgRegistryBackup:     "Registry backup failed, nothing was cleaned: %v",
+	MsgUnknownIDs:         "Unknown cleaner or option IDs",
+	MsgInvalidSizeBasis:   "Unknown size basis %q, use apparent or allocated",
+	MsgInvalidWipe:        "Invalid free space wipe: %v",
+	MsgWipeRunning:        "A free space wipe is already running",
+	MsgWipeMargin:         "Free space doesn't exceed the safety margin of %d bytes, nothing to wipe",
+	MsgWipeFailed:         "Free space wipe failed: %v",
+	MsgReviewCancelled:    "Review cancelled",
+	MsgCleanCancelled:     "Cleaning cancelled",
+	MsgWipeCancelled:      "Free space wipe cancelled",
+	MsgOperationCancelled: "Operation cancelled",
+	MsgNoOperation:        "No operation to cancel",
+
+	MsgReasonNoOptions:          "no options apply to %s",
+	MsgReasonNotDetected:        "not detected on this machine",
+	MsgReasonCleanerUnavailable: "cleaner is not available on %s",
+	MsgReasonOptionUnavailable:  "option is not available on %s",
+	MsgReasonNoActions:          "no actions for %s",
+
+	MsgRuleAlways:          "always",
+	MsgRuleAll:             "all: [%s]",
+	MsgRuleAny:             "any: %s",
+	MsgRuleNot:             "not: %s",
+	MsgRuleNotNone:         "not: no rule matched",
+	MsgRuleExecutable:      "executable in PATH: %s",
+	MsgRuleFile:            "file exists: %s",
+	MsgRuleDir:             "dir exists: %s",
+	MsgRulePath:            "path exists: %s",
+	MsgRuleContains:        "file contains /%s/: %s",
+	MsgRuleINIKey:          "ini key %s: %s",
+	MsgRuleJSONKey:         "json key %s: %s",
+	MsgRuleVersion:         "version %s >= %s: %s",
+	MsgRuleRegistryKey:     "registry key: %s",
+	MsgRuleRegistryValue:   "registry value: %s",
+	MsgRuleRegistryPattern: "registry value %s matches /%s/",
+}
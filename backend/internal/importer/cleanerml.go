@@ -0,0 +1,375 @@
+// Package importer converts third-party cleaner definition formats into
+// models.Cleaner so they can be loaded alongside the bundled resources.
+package importer
+
+import (
+	"backend/internal/models"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CleanerML document structure (BleachBit's cleaner markup language).
+// Elements and attributes we don't understand are captured by the ",any"
+// fields so they can be reported instead of silently ignored.
+type cmlCleaner struct {
+	ID          string       `xml:"id,attr"`
+	OS          string       `xml:"os,attr"`
+	Label       string       `xml:"label"`
+	Description string       `xml:"description"`
+	Running     []cmlRunning `xml:"running"`
+	Vars        []cmlVar     `xml:"var"`
+	Options     []cmlOption  `xml:"option"`
+	Unknown     []cmlAny     `xml:",any"`
+}
+
+type cmlRunning struct {
+	Type  string `xml:"type,attr"`
+	OS    string `xml:"os,attr"`
+	Value string `xml:",chardata"`
+}
+
+type cmlVar struct {
+	Name   string     `xml:"name,attr"`
+	Values []cmlValue `xml:"value"`
+}
+
+type cmlValue struct {
+	OS    string `xml:"os,attr"`
+	Value string `xml:",chardata"`
+}
+
+type cmlOption struct {
+	ID          string      `xml:"id,attr"`
+	OS          string      `xml:"os,attr"`
+	Label       string      `xml:"label"`
+	Description string      `xml:"description"`
+	Warning     string      `xml:"warning"`
+	Actions     []cmlAction `xml:"action"`
+	Unknown     []cmlAny    `xml:",any"`
+}
+
+type cmlAction struct {
+	Command string     `xml:"command,attr"`
+	Search  string     `xml:"search,attr"`
+	Path    string     `xml:"path,attr"`
+	OS      string     `xml:"os,attr"`
+	Type    string     `xml:"type,attr"`
+	Name    string     `xml:"name,attr"` // registry value name of winreg actions
+	Regex   string     `xml:"regex,attr"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+}
+
+type cmlAny struct {
+	XMLName xml.Name
+}
+
+// cleanerMLSearches maps CleanerML search types to the ones ProcessAction understands
+var cleanerMLSearches = map[string]string{
+	"file":       "file",
+	"glob":       "glob",
+	"walk.files": "walk.files",
+	"walk.all":   "walk.all",
+}
+
+// cleanerMLCommands maps CleanerML commands to models.Action commands
+var cleanerMLCommands = map[string]string{
+	"delete":   "delete",
+	"truncate": "truncate",
+	"shred":    "shred",
+}
+
+// cleanerMLOS maps CleanerML os attribute values to runtime.GOOS names
+var cleanerMLOS = map[string][]string{
+	"linux":   {"linux"},
+	"windows": {"windows"},
+	"darwin":  {"darwin"},
+	"bsd":     {"freebsd", "netbsd", "openbsd"},
+	"unix":    {"linux", "darwin", "freebsd", "netbsd", "openbsd"},
+}
+
+// ParseCleanerML converts a BleachBit CleanerML document into a models.Cleaner.
+//
+// Supported constructs are cleaner/option/action with delete, truncate, shred
+// and winreg commands, the file, glob, walk.files and walk.all search types, os filters
+// on any level, file name regex filters, <var> substitution and <running type="exe"> process checks.
+// Anything else is dropped and described in the returned warnings, including
+// sqlite.vacuum actions, which aren't supported; an action
+// with an attribute we can't honour (e.g. an nregex filter) is dropped entirely
+// rather than run with a wider scope than its author intended.
+//
+// Detection paths are the expanded action paths and registry checks the keys
+// of winreg actions, so the cleaner is listed only when at least one of its
+// targets exists.
+func ParseCleanerML(r io.Reader) (models.Cleaner, []string, error) {
+	var doc cmlCleaner
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return models.Cleaner{}, nil, fmt.Errorf("parse CleanerML: %w", err)
+	}
+
+	if doc.ID == "" {
+		return models.Cleaner{}, nil, errors.New("CleanerML cleaner has no id")
+	}
+
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	for _, element := range doc.Unknown {
+		warn("unsupported element <%s>", element.XMLName.Local)
+	}
+
+	cleanerOS, ok := mapCleanerMLOS(doc.OS)
+	if !ok {
+		warn("unknown os %q on cleaner, filter ignored", doc.OS)
+	}
+
+	cleaner := models.Cleaner{
+		ID:          doc.ID,
+		Name:        strings.TrimSpace(doc.Label),
+		Description: strings.TrimSpace(doc.Description),
+		Detect:      models.Detection{},
+		OS:          cleanerOS,
+	}
+	if cleaner.Name == "" {
+		cleaner.Name = doc.ID
+	}
+
+	for _, running := range doc.Running {
+		if running.Type != "exe" {
+			warn("unsupported running check type %q", running.Type)
+			continue
+		}
+		cleaner.Processes = append(cleaner.Processes, strings.TrimSpace(running.Value))
+	}
+
+	vars := make(map[string][]cmlValue, len(doc.Vars))
+	for _, v := range doc.Vars {
+		vars[v.Name] = append(vars[v.Name], v.Values...)
+	}
+
+	for _, cmlOpt := range doc.Options {
+		for _, element := range cmlOpt.Unknown {
+			warn("option %q: unsupported element <%s>", cmlOpt.ID, element.XMLName.Local)
+		}
+
+		optionOS, ok := mapCleanerMLOS(cmlOpt.OS)
+		if !ok {
+			warn("option %q: unknown os %q, filter ignored", cmlOpt.ID, cmlOpt.OS)
+		}
+		optionOS, ok = intersectOS(cleanerOS, optionOS)
+		if !ok {
+			continue
+		}
+
+		option := models.Option{
+			ID:          cmlOpt.ID,
+			Label:       strings.TrimSpace(cmlOpt.Label),
+			Description: strings.TrimSpace(cmlOpt.Description),
+			Warning:     strings.TrimSpace(cmlOpt.Warning),
+			OS:          optionOS,
+		}
+
+		for _, cmlAct := range cmlOpt.Actions {
+			actions, reason := convertCleanerMLAction(cmlAct, optionOS, vars)
+			if reason != "" {
+				warn("option %q: action %q on %q skipped: %s", cmlOpt.ID, cmlAct.Command, cmlAct.Path, reason)
+				continue
+			}
+			option.Actions = append(option.Actions, actions...)
+		}
+
+		if len(option.Actions) == 0 {
+			warn("option %q has no supported actions, skipped", cmlOpt.ID)
+			continue
+		}
+
+		for _, action := range option.Actions {
+			if action.Command == models.RegistryDeleteKey || action.Command == models.RegistryDeleteValue {
+				cleaner.Detect.Registry = append(cleaner.Detect.Registry, models.RegistryCheck{Key: action.Path, OS: action.OS})
+				continue
+			}
+			if !slices.Contains(cleaner.Detect.Paths, action.Path) {
+				cleaner.Detect.Paths = append(cleaner.Detect.Paths, action.Path)
+			}
+		}
+
+		cleaner.Options = append(cleaner.Options, option)
+	}
+
+	if len(cleaner.Options) == 0 {
+		return cleaner, warnings, fmt.Errorf("CleanerML cleaner %q has no supported options", doc.ID)
+	}
+
+	return cleaner, warnings, nil
+}
+
+// convertCleanerMLAction maps a single <action> element, expanding $$var$$
+// references into one action per applicable value. A non-empty reason means
+// the action can't be imported.
+func convertCleanerMLAction(cmlAct cmlAction, optionOS []string, vars map[string][]cmlValue) ([]models.Action, string) {
+	if cmlAct.Command == "winreg" {
+		return convertCleanerMLRegistryAction(cmlAct, optionOS)
+	}
+
+	if cmlAct.Name != "" {
+		return nil, `unsupported attribute "name"`
+	}
+
+	command, ok := cleanerMLCommands[cmlAct.Command]
+	if !ok {
+		return nil, "unsupported command"
+	}
+
+	search, ok := cleanerMLSearches[cmlAct.Search]
+	if !ok {
+		return nil, fmt.Sprintf("unsupported search %q", cmlAct.Search)
+	}
+
+	if cmlAct.Type != "" && cmlAct.Type != "f" {
+		return nil, fmt.Sprintf("unsupported type %q", cmlAct.Type)
+	}
+
+	if len(cmlAct.Attrs) > 0 {
+		return nil, fmt.Sprintf("unsupported attribute %q", cmlAct.Attrs[0].Name.Local)
+	}
+
+	if cmlAct.Regex != "" {
+		if _, err := regexp.Compile(cmlAct.Regex); err != nil {
+			return nil, fmt.Sprintf("unsupported regex %q", cmlAct.Regex)
+		}
+	}
+
+	actionOS, ok := mapCleanerMLOS(cmlAct.OS)
+	if !ok {
+		return nil, fmt.Sprintf("unknown os %q", cmlAct.OS)
+	}
+	actionOS, ok = intersectOS(optionOS, actionOS)
+	if !ok {
+		return nil, "os filter excludes every os of the option"
+	}
+
+	var actions []models.Action
+	for _, expanded := range expandCleanerMLVars(cmlAct.Path, actionOS, vars) {
+		actions = append(actions, models.Action{
+			Command: command,
+			Search:  search,
+			Path:    convertCleanerMLPath(expanded.path),
+			OS:      expanded.os,
+			Regex:   cmlAct.Regex,
+		})
+	}
+
+	return actions, ""
+}
+
+// convertCleanerMLRegistryAction maps <action command="winreg" path="KEY" [name="VALUE"]/>
+// to registry.delete_value when a value is named and registry.delete_key otherwise.
+func convertCleanerMLRegistryAction(cmlAct cmlAction, optionOS []string) ([]models.Action, string) {
+	if len(cmlAct.Attrs) > 0 {
+		return nil, fmt.Sprintf("unsupported attribute %q", cmlAct.Attrs[0].Name.Local)
+	}
+	if cmlAct.Regex != "" {
+		return nil, `unsupported attribute "regex"`
+	}
+
+	actionOS, ok := intersectOS(optionOS, []string{"windows"})
+	if !ok {
+		return nil, "registry actions only apply to windows"
+	}
+
+	action, reason := registryAction(cmlAct.Path, cmlAct.Name)
+	if reason != "" {
+		return nil, reason
+	}
+	action.OS = actionOS
+
+	return []models.Action{action}, ""
+}
+
+type expandedPath struct {
+	path string
+	os   []string
+}
+
+// expandCleanerMLVars substitutes the first $$name$$ reference with every value
+// of the variable whose os filter is compatible, then recurses for the rest.
+// Unknown variables are left in place.
+func expandCleanerMLVars(path string, osList []string, vars map[string][]cmlValue) []expandedPath {
+	start := strings.Index(path, "$$")
+	if start < 0 {
+		return []expandedPath{{path: path, os: osList}}
+	}
+
+	end := strings.Index(path[start+2:], "$$")
+	if end < 0 {
+		return []expandedPath{{path: path, os: osList}}
+	}
+	end += start + 2
+
+	values, ok := vars[path[start+2:end]]
+	if !ok {
+		return []expandedPath{{path: path, os: osList}}
+	}
+
+	var result []expandedPath
+	for _, value := range values {
+		valueOS, ok := mapCleanerMLOS(value.OS)
+		if !ok {
+			continue
+		}
+		combinedOS, ok := intersectOS(osList, valueOS)
+		if !ok {
+			continue
+		}
+
+		substituted := path[:start] + strings.TrimSpace(value.Value) + path[end+2:]
+		result = append(result, expandCleanerMLVars(substituted, combinedOS, vars)...)
+	}
+
+	return result
+}
+
+// convertCleanerMLPath rewrites BleachBit's "~" home shorthand into an
+// environment reference that detector.ExpandPath understands.
+func convertCleanerMLPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return "$HOME" + path[1:]
+	}
+	return path
+}
+
+// mapCleanerMLOS converts a CleanerML os attribute into runtime.GOOS names.
+// An empty attribute means every OS; ok is false for values we don't know.
+func mapCleanerMLOS(value string) ([]string, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	osList, ok := cleanerMLOS[strings.ToLower(value)]
+	return osList, ok
+}
+
+// intersectOS combines two OS filters where nil means "any OS".
+// ok is false when the filters have nothing in common.
+func intersectOS(a, b []string) ([]string, bool) {
+	if a == nil {
+		return b, true
+	}
+	if b == nil {
+		return a, true
+	}
+
+	var common []string
+	for _, os := range a {
+		if slices.Contains(b, os) {
+			common = append(common, os)
+		}
+	}
+	return common, len(common) > 0
+}
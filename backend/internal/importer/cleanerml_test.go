@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCleanerML(t *testing.T) {
+	file, err := os.Open("testdata/sample.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	cleaner, warnings, err := ParseCleanerML(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cleaner.ID != "sample" || cleaner.Name != "Sample" || strings.Join(cleaner.OS, ",") != "linux" {
+		t.Errorf("got cleaner %q %q on %v", cleaner.ID, cleaner.Name, cleaner.OS)
+	}
+	if strings.Join(cleaner.Processes, ",") != "sample" {
+		t.Errorf("running check not mapped: %v", cleaner.Processes)
+	}
+
+	// the windows option is dropped, as is the history option, whose vacuum and
+	// registry actions are unsupported here; the windows-only variable value isn't expanded
+	if len(cleaner.Options) != 1 {
+		t.Fatalf("got %d options, want 1", len(cleaner.Options))
+	}
+
+	cache := cleaner.Options[0].Actions
+	if len(cache) != 2 || cache[0].Path != "$HOME/.sample/cache" || cache[0].Search != "walk.files" {
+		t.Fatalf("cache actions: %+v", cache)
+	}
+	if cache[1].Path != "$HOME/.sample/dumps" || cache[1].Regex != `\.dmp$` {
+		t.Errorf("regex action: %+v", cache[1])
+	}
+
+	if strings.Join(cleaner.Detect.Paths, ";") != "$HOME/.sample/cache;$HOME/.sample/dumps" {
+		t.Errorf("detection paths: %v", cleaner.Detect.Paths)
+	}
+
+	for _, want := range []string{`unsupported attribute "nregex"`, "registry actions only apply to windows",
+		`action "sqlite.vacuum" on "~/.sample/history.db" skipped: unsupported command`, `option "history" has no supported actions`} {
+		if !containsWarning(warnings, want) {
+			t.Errorf("no warning containing %q in %q", want, warnings)
+		}
+	}
+}
+
+func TestParseCleanerMLRegistry(t *testing.T) {
+	document := `<cleaner id="reg" os="windows"><label>Reg</label>
+		<option id="mru"><label>MRU</label><description/>
+			<action command="winreg" path="HKCU\Software\App\Recent" name="File*1"/>
+			<action command="winreg" path="HKCU\Software\App\Cache"/>
+			<action command="winreg" path="HKCU\Software"/>
+		</option></cleaner>`
+
+	cleaner, warnings, err := ParseCleanerML(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions := cleaner.Options[0].Actions
+	if len(actions) != 2 {
+		t.Fatalf("got %+v, want two actions", actions)
+	}
+	if actions[0].Command != "registry.delete_value" || actions[0].Value != `File\*1` {
+		t.Errorf("named winreg action: %+v", actions[0])
+	}
+	if actions[1].Command != "registry.delete_key" || actions[1].Path != `HKCU\Software\App\Cache` {
+		t.Errorf("winreg key action: %+v", actions[1])
+	}
+	if len(cleaner.Detect.Registry) != 2 || len(cleaner.Detect.Paths) != 0 {
+		t.Errorf("registry actions should detect by key: %+v", cleaner.Detect)
+	}
+	if !containsWarning(warnings, "top-level registry key") {
+		t.Errorf("no warning for the top-level key in %q", warnings)
+	}
+}
@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"backend/internal/detector"
+	"backend/internal/models"
+	"strings"
+)
+
+// registryPatternEscaper escapes the characters value name patterns treat specially
+var registryPatternEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+
+// registryAction builds a registry.delete_value action for a named value, or a
+// registry.delete_key action for the whole key. Imported value names are
+// literal, so they are escaped. A non-empty reason means the key can't be
+// imported, e.g. because deleting it would take out a top-level key.
+func registryAction(key string, value string) (models.Action, string) {
+	key = strings.TrimSpace(key)
+	_, subkey, ok := detector.SplitRegistryKey(key)
+	if !ok {
+		return models.Action{}, "unknown registry root"
+	}
+
+	if value != "" {
+		return models.Action{
+			Command: models.RegistryDeleteValue,
+			Path:    key,
+			Value:   registryPatternEscaper.Replace(value),
+		}, ""
+	}
+
+	if !strings.Contains(subkey, `\`) {
+		return models.Action{}, "refusing to delete a top-level registry key"
+	}
+	return models.Action{Command: models.RegistryDeleteKey, Path: key}, ""
+}
@@ -0,0 +1,353 @@
+package importer
+
+import (
+	"backend/internal/models"
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// winapp2Entry is one [Section] of a winapp2.ini file with its keys in file order.
+type winapp2Entry struct {
+	name string
+	line int
+	keys []winapp2Key
+}
+
+type winapp2Key struct {
+	name  string // lower-cased key name without its number, e.g. "filekey"
+	value string
+}
+
+// winapp2SpecialDetect maps the SpecialDetect values CCleaner resolves
+// internally to the paths that indicate the application is installed
+var winapp2SpecialDetect = map[string][]string{
+	"det_chrome":      {`%LocalAppData%\Google\Chrome\User Data`},
+	"det_firefox":     {`%AppData%\Mozilla\Firefox`},
+	"det_thunderbird": {`%AppData%\Thunderbird`},
+	"det_opera":       {`%AppData%\Opera Software`},
+}
+
+var winapp2KeyNumber = regexp.MustCompile(`^([A-Za-z]+?)\d*$`)
+
+var winapp2IDCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ParseWinapp2 converts the entries of a winapp2.ini file into cleaners, one
+// cleaner with a single "clean" option per entry.
+//
+// Detect/DetectN become registry checks and DetectFile/DetectFileN become
+// detection paths; an entry without any of them is always shown.
+// FileKeyN=path|patterns|flags become delete actions: non-recursive keys are
+// globbed per pattern, RECURSE keys walk the folder with the patterns as
+// include filters and REMOVESELF keys do the same, then remove the folders
+// left empty including the folder itself. ExcludeKeyN FILE and PATH rules become action exclusions.
+// RegKeyN=key|value deletes the value, RegKeyN=key the whole key.
+//
+// Entries and keys that can't be mapped are reported in the returned
+// warnings. An entry is skipped when something it relies on for safety
+// (an unknown SpecialDetect, an unsupported ExcludeKey, a DetectOS Windows
+// version limit) can't be honoured.
+func ParseWinapp2(r io.Reader) ([]models.Cleaner, []string, error) {
+	entries, err := readWinapp2(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cleaners []models.Cleaner
+	var warnings []string
+	usedIDs := make(map[string]int)
+
+	for _, entry := range entries {
+		cleaner, entryWarnings, ok := convertWinapp2Entry(entry)
+		for _, warning := range entryWarnings {
+			warnings = append(warnings, fmt.Sprintf("[%s] (line %d): %s", entry.name, entry.line, warning))
+		}
+		if !ok {
+			continue
+		}
+
+		usedIDs[cleaner.ID]++
+		if count := usedIDs[cleaner.ID]; count > 1 {
+			cleaner.ID += "_" + strconv.Itoa(count)
+		}
+
+		cleaners = append(cleaners, cleaner)
+	}
+
+	return cleaners, warnings, nil
+}
+
+// readWinapp2 splits the ini file into entries. Comments start with ';'.
+func readWinapp2(r io.Reader) ([]winapp2Entry, error) {
+	var entries []winapp2Entry
+	var current *winapp2Entry
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			entries = append(entries, winapp2Entry{
+				name: strings.TrimSpace(line[1 : len(line)-1]),
+				line: lineNumber,
+			})
+			current = &entries[len(entries)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("winapp2.ini line %d: expected key=value", lineNumber)
+		}
+
+		key := strings.TrimSpace(name)
+		if match := winapp2KeyNumber.FindStringSubmatch(key); match != nil {
+			key = match[1]
+		}
+
+		current.keys = append(current.keys, winapp2Key{
+			name:  strings.ToLower(key),
+			value: strings.TrimSpace(value),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read winapp2.ini: %w", err)
+	}
+
+	return entries, nil
+}
+
+// convertWinapp2Entry maps a single entry. ok is false when the entry must be skipped.
+func convertWinapp2Entry(entry winapp2Entry) (models.Cleaner, []string, bool) {
+	var warnings []string
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	name := strings.TrimSpace(strings.TrimSuffix(entry.name, "*"))
+	cleaner := models.Cleaner{
+		ID:   "winapp2_" + strings.Trim(winapp2IDCleaner.ReplaceAllString(strings.ToLower(name), "_"), "_"),
+		Name: name,
+		OS:   []string{"windows"}, // winapp2 paths and registry keys are Windows-only
+	}
+
+	option := models.Option{
+		ID:    "clean",
+		Label: name,
+	}
+
+	var fileKeys []string
+	var excludes []string
+	var regKeys []models.Action
+
+	for _, key := range entry.keys {
+		switch key.name {
+		case "detect":
+			cleaner.Detect.Registry = append(cleaner.Detect.Registry, models.RegistryCheck{
+				Key: key.value,
+				OS:  []string{"windows"},
+			})
+		case "detectfile":
+			cleaner.Detect.Paths = append(cleaner.Detect.Paths, strings.TrimRight(key.value, `\`))
+		case "specialdetect":
+			paths, ok := winapp2SpecialDetect[strings.ToLower(key.value)]
+			if !ok {
+				warn("unsupported SpecialDetect %q, entry skipped", key.value)
+				return cleaner, warnings, false
+			}
+			cleaner.Detect.Paths = append(cleaner.Detect.Paths, paths...)
+		case "filekey":
+			fileKeys = append(fileKeys, key.value)
+		case "excludekey":
+			exclude, widened, reason := convertWinapp2Exclude(key.value)
+			if reason != "" {
+				warn("ExcludeKey %q: %s, entry skipped", key.value, reason)
+				return cleaner, warnings, false
+			}
+			if widened {
+				warn("ExcludeKey %q widened to the whole folder", key.value)
+			}
+			excludes = append(excludes, exclude...)
+		case "warning":
+			option.Warning = key.value
+		case "section":
+			cleaner.Description = key.value
+		case "regkey":
+			keyPath, valueName, _ := strings.Cut(key.value, "|")
+			action, reason := registryAction(keyPath, valueName)
+			if reason != "" {
+				warn("RegKey %q: %s, ignored", key.value, reason)
+				continue
+			}
+			action.OS = []string{"windows"}
+			regKeys = append(regKeys, action)
+		case "detectos":
+			// cleaners can't be limited to Windows versions, so an entry meant for
+			// some of them only would run on all
+			warn("DetectOS %q: Windows version limits aren't supported, entry skipped", key.value)
+			return cleaner, warnings, false
+		case "langsecref", "default":
+			// CCleaner UI metadata, not needed here
+		default:
+			warn("unknown key %q ignored", key.name)
+		}
+	}
+
+	for _, fileKey := range fileKeys {
+		actions, reason := convertWinapp2FileKey(fileKey, excludes)
+		if reason != "" {
+			warn("FileKey %q: %s", fileKey, reason)
+		}
+		option.Actions = append(option.Actions, actions...)
+	}
+
+	option.Actions = append(option.Actions, regKeys...)
+
+	if len(option.Actions) == 0 {
+		warn("no supported FileKey or RegKey, entry skipped")
+		return cleaner, warnings, false
+	}
+
+	if len(cleaner.Detect.Paths) == 0 && len(cleaner.Detect.Registry) == 0 {
+		cleaner.Detect.Type = "always"
+	}
+
+	cleaner.Options = []models.Option{option}
+	return cleaner, warnings, true
+}
+
+// convertWinapp2FileKey maps "path|pattern1;pattern2|FLAG" to delete actions.
+// A non-empty reason is a warning; actions may still be returned.
+func convertWinapp2FileKey(value string, excludes []string) ([]models.Action, string) {
+	parts := strings.Split(value, "|")
+	dir := strings.TrimRight(parts[0], `\`)
+	if dir == "" {
+		return nil, "empty path"
+	}
+
+	patterns := []string{"*"}
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		patterns = nil
+		for _, pattern := range strings.Split(parts[1], ";") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, normalizeWinapp2Pattern(pattern))
+			}
+		}
+	}
+
+	var flag string
+	if len(parts) > 2 {
+		flag = strings.ToUpper(strings.TrimSpace(parts[2]))
+	}
+
+	removeSelf := false
+	switch flag {
+	case "":
+		actions := make([]models.Action, 0, len(patterns))
+		for _, pattern := range patterns {
+			actions = append(actions, models.Action{
+				Command: "delete",
+				Search:  "glob",
+				Path:    dir + `\` + pattern,
+				OS:      []string{"windows"},
+				Exclude: excludes,
+			})
+		}
+		return actions, ""
+	case "REMOVESELF":
+		removeSelf = true
+	case "RECURSE":
+	default:
+		return nil, fmt.Sprintf("unsupported flag %q", flag)
+	}
+
+	action := models.Action{
+		Command: "delete",
+		Search:  "walk.files",
+		Path:    dir,
+		OS:      []string{"windows"},
+		Exclude: excludes,
+	}
+	if removeSelf {
+		action.Search = "walk.all"
+		action.RemoveRoot = true
+	}
+	if !matchesEverything(patterns) {
+		action.Include = patterns
+	}
+
+	return []models.Action{action}, ""
+}
+
+// convertWinapp2Exclude maps "FILE|folder\|file1;file2" and "PATH|folder\|pattern"
+// rules to exclusion patterns. PATH rules with a pattern are recursive in
+// winapp2, which plain patterns can't express, so they are widened to the whole
+// folder to stay on the safe side and widened is set.
+func convertWinapp2Exclude(value string) (excludes []string, widened bool, reason string) {
+	parts := strings.SplitN(value, "|", 3)
+	if len(parts) < 2 {
+		return nil, false, "malformed rule"
+	}
+
+	dir := strings.TrimRight(parts[1], `\`)
+	var patterns []string
+	if len(parts) > 2 {
+		for _, pattern := range strings.Split(parts[2], ";") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				patterns = append(patterns, normalizeWinapp2Pattern(pattern))
+			}
+		}
+	}
+
+	switch strings.ToUpper(parts[0]) {
+	case "FILE":
+		if len(patterns) == 0 {
+			return []string{dir}, false, ""
+		}
+		for _, pattern := range patterns {
+			excludes = append(excludes, dir+`\`+pattern)
+		}
+		return excludes, false, ""
+	case "PATH":
+		return []string{dir}, !matchesEverything(patterns), ""
+	default:
+		return nil, false, fmt.Sprintf("unsupported exclusion type %q", parts[0])
+	}
+}
+
+// matchesEverything reports whether the patterns match any file name.
+// No patterns at all counts as matching everything.
+func matchesEverything(patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern != "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeWinapp2Pattern converts the DOS "*.*" catch-all into "*", since
+// filepath.Match would otherwise require a dot in the name.
+func normalizeWinapp2Pattern(pattern string) string {
+	if pattern == "*.*" {
+		return "*"
+	}
+	return pattern
+}
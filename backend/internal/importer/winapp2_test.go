@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"backend/internal/detector"
+	"backend/internal/models"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseWinapp2(t *testing.T) {
+	file, err := os.Open("testdata/winapp2.ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	cleaners, warnings, err := ParseWinapp2(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make([]string, len(cleaners))
+	for i, cleaner := range cleaners {
+		ids[i] = cleaner.ID
+	}
+	if got, want := strings.Join(ids, ","), "winapp2_sample_app,winapp2_always_shown,winapp2_registry_only"; got != want {
+		t.Fatalf("got cleaners %s, want %s", got, want)
+	}
+
+	sample := cleaners[0]
+	if len(sample.Detect.Registry) != 1 || sample.Detect.Registry[0].Key != `HKCU\Software\Sample` {
+		t.Errorf("Detect not mapped to a registry check: %+v", sample.Detect.Registry)
+	}
+	if len(sample.Detect.Paths) != 1 || sample.Detect.Paths[0] != `%AppData%\Sample` {
+		t.Errorf("DetectFile not mapped to a path: %v", sample.Detect.Paths)
+	}
+
+	wantActions := []models.Action{
+		{Command: "delete", Search: "glob", Path: `%AppData%\Sample\Cache\*`},
+		{Command: "delete", Search: "walk.files", Path: `%AppData%\Sample\Logs`, Include: []string{"*.log", "*.txt"}},
+		{Command: "delete", Search: "walk.all", Path: `%AppData%\Sample\Shaders`, RemoveRoot: true},
+		{Command: models.RegistryDeleteValue, Path: `HKCU\Software\Sample\Recent`, Value: `MRU\*`},
+		{Command: models.RegistryDeleteKey, Path: `HKCU\Software\Sample\History`},
+	}
+	actions := sample.Options[0].Actions
+	if len(actions) != len(wantActions) {
+		t.Fatalf("got %d actions, want %d: %+v", len(actions), len(wantActions), actions)
+	}
+	for i, want := range wantActions {
+		got := actions[i]
+		if got.Command != want.Command || got.Search != want.Search || got.Path != want.Path ||
+			got.Value != want.Value || got.RemoveRoot != want.RemoveRoot ||
+			strings.Join(got.Include, ";") != strings.Join(want.Include, ";") {
+			t.Errorf("action %d: got %+v, want %+v", i, got, want)
+		}
+	}
+	if exclude := actions[0].Exclude; len(exclude) != 1 || exclude[0] != `%AppData%\Sample\Cache\keep.dat` {
+		t.Errorf("ExcludeKey not applied: %v", exclude)
+	}
+
+	if cleaners[1].Detect.Type != "always" {
+		t.Errorf("entry without detection has type %q, want always", cleaners[1].Detect.Type)
+	}
+
+	// the fixture's mixed-case %Temp% resolves like Windows resolves it
+	env := map[string]string{"TEMP": `C:\Users\me\AppData\Local\Temp`, "AppData": `C:\Users\me\AppData\Roaming`}
+	getenv := func(name string) string { return env[name] }
+	always := cleaners[1].Options[0].Actions[0].Path
+	if got, want := detector.ExpandWindowsVariables(always, getenv), `C:\Users\me\AppData\Local\Temp\Always\*.tmp`; got != want {
+		t.Errorf("expanded path %q, want %q", got, want)
+	}
+	if got, want := detector.ExpandWindowsVariables(sample.Detect.Paths[0], getenv), `C:\Users\me\AppData\Roaming\Sample`; got != want {
+		t.Errorf("expanded detection path %q, want %q", got, want)
+	}
+
+	wantWarnings := []string{`SpecialDetect "DET_NOTHING"`, `RegKey "HKCU\\Software": refusing`, `DetectOS "|6.1"`}
+	for _, want := range wantWarnings {
+		if !containsWarning(warnings, want) {
+			t.Errorf("no warning containing %q in %q", want, warnings)
+		}
+	}
+}
+
+func TestParseWinapp2Malformed(t *testing.T) {
+	_, _, err := ParseWinapp2(strings.NewReader("[Entry *]\nnot a key\n"))
+	if err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func containsWarning(warnings []string, text string) bool {
+	for _, warning := range warnings {
+		if strings.Contains(warning, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes. In definitions it is either a number of bytes
+// or a string with a unit such as "500MB" or "1.5 GiB"; units are binary,
+// so "1KB" and "1KiB" are both 1024 bytes.
+type ByteSize uint64
+
+var byteUnits = map[string]uint64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+// ParseByteSize parses a size such as "500MB", "1.5 GiB" or "4096"
+func ParseByteSize(s string) (ByteSize, error) {
+	text := strings.TrimSpace(s)
+	end := strings.LastIndexAny(text, "0123456789.") + 1
+	number, unit := strings.TrimSpace(text[:end]), strings.ToLower(strings.TrimSpace(text[end:]))
+
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, text[end:])
+	}
+
+	if whole, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if whole > math.MaxUint64/multiplier {
+			return 0, fmt.Errorf("invalid size %q: too large", s)
+		}
+		return ByteSize(whole * multiplier), nil
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	size := value * float64(multiplier)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return ByteSize(size), nil
+}
+
+// UnmarshalJSON accepts a number of bytes or a size string
+func (size *ByteSize) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		parsed, err := ParseByteSize(text)
+		if err != nil {
+			return err
+		}
+		*size = parsed
+		return nil
+	}
+
+	var n uint64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid size %s: want a number of bytes or a string like \"500MB\"", data)
+	}
+	*size = ByteSize(n)
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  ByteSize
+	}{
+		{"0", 0},
+		{"4096", 4096},
+		{"12B", 12},
+		{"500MB", 500 << 20},
+		{"500 mb", 500 << 20},
+		{"1.5 GiB", 3 << 29},
+		{"2k", 2048},
+		{" 1TB ", 1 << 40},
+	}
+	for _, test := range tests {
+		got, err := ParseByteSize(test.input)
+		if err != nil || got != test.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", test.input, got, err, test.want)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "-1", "-1MB", "5 XB", "1.2.3", "20000000TB"} {
+		if got, err := ParseByteSize(input); err == nil {
+			t.Errorf("ParseByteSize(%q) = %d, want an error", input, got)
+		}
+	}
+}
+
+func TestByteSizeJSON(t *testing.T) {
+	var action Action
+	if err := json.Unmarshal([]byte(`{"min_size": 1024, "keep_total_size": "500MB"}`), &action); err != nil {
+		t.Fatal(err)
+	}
+	if action.MinSize != 1024 || action.KeepTotalSize != 500<<20 {
+		t.Errorf("got min_size %d and keep_total_size %d", action.MinSize, action.KeepTotalSize)
+	}
+
+	for _, document := range []string{`{"max_size": -5}`, `{"max_size": "lots"}`, `{"max_size": true}`} {
+		if err := json.Unmarshal([]byte(document), &action); err == nil {
+			t.Errorf("%s: no error", document)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // models for backend
 
 // Cleaner defines a type representing a cleaning operation with associated options and metadata.
@@ -7,44 +9,218 @@ type Cleaner struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	Running bool      `json:"running"`
-	Detect  Detection `json:"detect"`
-	Options []Option  `json:"options"`
+	Running     bool      `json:"running"`
+	Processes   []string  `json:"processes,omitempty"` // executable names that indicate the app is running
+	Detect      Detection `json:"detect"`
+	Options     []Option  `json:"options"`
+	// OS and Arch restrict the whole cleaner to these GOOS/GOARCH values; empty means any
+	OS   []string `json:"os,omitempty"`
+	Arch []string `json:"arch,omitempty"`
+	// Detected, Installed, SupportedOnOS and Reason are filled in when the cleaner is listed
+	Detected      *DetectionResult `json:"detected,omitempty"`
+	Installed     bool             `json:"installed"`
+	SupportedOnOS bool             `json:"supported_on_os"` // at least one option applies to this OS
+	Reason        string           `json:"reason,omitempty"`
+	ReasonID      string           `json:"reason_id,omitempty"` // message ID of Reason, which is localized when listed
+	ReasonArgs    []any            `json:"-"`
+	// Profiles enables per-profile expansion of actions whose path contains {{profile}}
+	Profiles *ProfileDiscovery `json:"profiles,omitempty"`
+
+	// Variables and Templates are only used while loading definitions: template
+	// options are merged in and {{name}} placeholders replaced, then both are cleared.
+	Variables map[string]string `json:"variables,omitempty"`
+	Templates []string          `json:"templates,omitempty"`
+
+	// Translations holds localized strings keyed by locale (e.g. "uk"). They are
+	// applied and cleared before the cleaner is returned by the API.
+	Translations map[string]CleanerTranslation `json:"translations,omitempty"`
+}
+
+// CleanerTranslation holds the localized strings of a cleaner for one locale.
+// Empty strings fall back to the English originals.
+type CleanerTranslation struct {
+	Name        string                       `json:"name,omitempty"`
+	Description string                       `json:"description,omitempty"`
+	Options     map[string]OptionTranslation `json:"options,omitempty"` // keyed by option ID
+}
+
+// OptionTranslation holds the localized strings of a single option.
+type OptionTranslation struct {
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+}
+
+// Template is a reusable set of options shared by several cleaner definitions,
+// e.g. the cache folders every Chromium-based browser has.
+type Template struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description,omitempty"`
+	Profiles    *ProfileDiscovery `json:"profiles,omitempty"` // used by cleaners that don't set their own
+	Options     []Option          `json:"options"`
+	// Translations of the template's options, merged into each cleaner using it
+	Translations map[string]CleanerTranslation `json:"translations,omitempty"`
+}
+
+// ProfileDiscovery describes how to find the profiles of a multi-profile application.
+type ProfileDiscovery struct {
+	Type    string `json:"type"`              // "glob", "chromium" (Local State), "firefox" (profiles.ini)
+	Root    string `json:"root"`              // folder that holds the profiles
+	Pattern string `json:"pattern,omitempty"` // "glob" only: profile folder pattern relative to Root, default "*"
+}
+
+// Profile is a single discovered application profile.
+type Profile struct {
+	ID   string // folder name, e.g. "Profile 1", or for Firefox the profiles.ini path, e.g. "Profiles/abc.default"
+	Name string // display name, if the application records one
+	Path string // absolute path of the profile folder
 }
 
+// Detection describes how to tell whether an application is installed.
+//
+// Leaf types check Paths (any one matching is enough) and, on Windows,
+// Registry keys as alternatives. "all", "any" and "not" combine Rules.
 type Detection struct {
-	Type    	string   `json:"type"` // "file", "always", "dir", "registry"
-	Paths   	[]string           `json:"paths"`
-	Registry 	[]RegistryCheck `json:"registry"`
+	Type     string          `json:"type"` // "path", "file", "dir", "always", "registry", "executable", "contains", "ini_key", "json_key", "version", "all", "any", "not"
+	Paths    []string        `json:"paths"`
+	Registry []RegistryCheck `json:"registry"`
+
+	Rules      []Detection `json:"rules,omitempty"`       // "all", "any", "not": nested rules
+	Pattern    string      `json:"pattern,omitempty"`     // "contains": regex the file content must match; "ini_key"/"json_key": optional regex for the value; "version": regex capturing the version
+	Key        string      `json:"key,omitempty"`         // "ini_key": "Section.Key"; "json_key": dotted path, e.g. "profile.info_cache"
+	MinVersion string      `json:"min_version,omitempty"` // "version": lowest accepted dotted version
+}
+
+// DetectionResult explains the outcome of evaluating a cleaner's Detection.
+type DetectionResult struct {
+	Installed  bool      `json:"installed"`
+	Rule       string    `json:"rule,omitempty"`    // the rule that decided the outcome, e.g. "dir exists: C:\\..."
+	RuleID     string    `json:"rule_id,omitempty"` // message ID of Rule, which is localized when listed
+	RuleArgs   []any     `json:"-"`
+	DetectedAt time.Time `json:"detected_at"` // when the rule was evaluated; older than now when served from cache
+}
+
+// Message is a message ID with the arguments of its text, rendered in the
+// client's locale by the i18n package. Arguments may be Messages themselves,
+// or lists of them.
+type Message struct {
+	ID   string
+	Args []any
 }
 
 type RegistryCheck struct {
-	Key string   `json:"key"`
-	OS  []string `json:"os,omitempty"`
+	Key     string   `json:"key"`
+	Value   string   `json:"value,omitempty"`   // value name that must exist; "" with a pattern is the default value
+	Pattern string   `json:"pattern,omitempty"` // regular expression the value's text must match
+	OS      []string `json:"os,omitempty"`
 }
 
 // Option defines a type representing a cleaning operation option.
 type Option struct {
-	ID          string 		`json:"id"`
-	Label       string 		`json:"label"` // Label represents the human-readable name for the option in the cleaning operation.
-	Description string 		`json:"description"`
+	ID          string   `json:"id"`
+	Label       string   `json:"label"` // Label represents the human-readable name for the option in the cleaning operation.
+	Description string   `json:"description"`
 	Warning     string   `json:"warning,omitempty"`
 	Actions     []Action `json:"actions"`
+	OS          []string `json:"os,omitempty"`   // restricts the option to these GOOS values; empty means any
+	Arch        []string `json:"arch,omitempty"` // restricts the option to these GOARCH values; empty means any
+	// Applicable and Reason are filled in when the cleaner is listed
+	Applicable bool   `json:"applicable"`
+	Reason     string `json:"reason,omitempty"`
+	ReasonID   string `json:"reason_id,omitempty"` // message ID of Reason, which is localized when listed
+	ReasonArgs []any  `json:"-"`
 }
 
 type Action struct {
-	Command string 		`json:"command"` // "delete", "truncate", "vacuum"
-	Search  string 		`json:"search"` // "file", "glob", "walk.files"
-	Path    string 		`json:"path"`
-	OS 	    []string 	`json:"os,omitempty"`
+	Command string   `json:"command"`         // "delete", "truncate", "shred", "vacuum", "registry.delete_key", "registry.delete_value"
+	Search  string   `json:"search"`          // "file", "glob", "walk.files", "walk.all", "walk.dirs", "walk.top"; unused by registry commands
+	Path    string   `json:"path"`            // file path, or registry key (HKCU\...) for registry commands
+	Value   string   `json:"value,omitempty"` // value name pattern for registry.delete_value, e.g. "MRU*"
+	OS      []string `json:"os,omitempty"`
+	Include []string `json:"include,omitempty"` // file name patterns to target, e.g. "*.log"; empty means every file
+	Exclude []string `json:"exclude,omitempty"` // paths or path patterns to leave untouched, including everything beneath them
+	Regex   string   `json:"regex,omitempty"`   // regular expression file names must also match, e.g. "^f_[0-9a-f]{6}$"
+
+	// DeleteEmptyDirs removes the folders a walk leaves empty, bottom-up, as
+	// "walk.all" always does. The walk root is kept unless RemoveRoot is set.
+	DeleteEmptyDirs bool `json:"delete_empty_dirs,omitempty"`
+	RemoveRoot      bool `json:"remove_root,omitempty"`
+	// Depth limits "walk.files", "walk.all" and "walk.dirs" to that many levels
+	// below Path, 1 being its children; 0 walks the whole tree
+	Depth int `json:"depth,omitempty"`
+
+	// MinSize and MaxSize limit the files to those at least and at most that
+	// large; a zero MaxSize means no upper limit
+	MinSize ByteSize `json:"min_size,omitempty"`
+	MaxSize ByteSize `json:"max_size,omitempty"`
+	// KeepNewest and KeepTotalSize spare the newest matching files: the N most
+	// recently modified, and as many of the newest as fit in that many bytes.
+	// With both set a file is kept only if it is within both; the rest are targeted.
+	KeepNewest    int      `json:"keep_newest,omitempty"`
+	KeepTotalSize ByteSize `json:"keep_total_size,omitempty"`
+
+	// Passes and Fill set how "shred" overwrites a file before deleting it:
+	// that many times (1 if unset) with random bytes, or zeros with Fill "zero".
+	// A file with other hard links is only unlinked, as overwriting it would
+	// destroy the data the other names still show.
+	Passes int    `json:"passes,omitempty"`
+	Fill   string `json:"fill,omitempty"`
+
+	Profile *Profile `json:"-"` // set on actions expanded from a {{profile}} path
+	// Processes are copied from the cleaner when actions are loaded for
+	// cleaning, which skips the option while one of them is running
+	Processes []string `json:"-"`
 }
 
+// Registry cleaning commands of an Action
+const (
+	RegistryDeleteKey   = "registry.delete_key"
+	RegistryDeleteValue = "registry.delete_value"
+)
+
+// Fill patterns of the "shred" command and free space wipes
+const (
+	ShredFillRandom = "random"
+	ShredFillZero   = "zero"
+)
+
+// MaxShredPasses bounds Action.Passes
+const MaxShredPasses = 35
+
 type ActionResult struct {
-	Size      uint64
-	FileCount uint64
-	Paths     []string
+	Size          uint64
+	AllocatedSize uint64
+	FileCount     uint64
+	DirCount      uint64
+	Paths         []string
+	Registry      []RegistryEntry
+	Profile       *Profile
+	Errors        []ItemError
+	Summary       []ErrorSummary
+	// ShredBytes and ShredIneffective are set for "shred" actions, see AnalyzeItem
+	ShredBytes       uint64
+	ShredIneffective uint64
+}
+
+// ItemError - problem met while handling a single cleaner option
+type ItemError struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
 }
 
+// ErrorSummary - number of errors of one kind with a few of the affected paths
+type ErrorSummary struct {
+	Kind    string   `json:"kind"`
+	Count   uint64   `json:"count"`
+	Samples []string `json:"samples"`
+}
+
+// RegistryEntry - registry key or value targeted by a registry cleaning action
+type RegistryEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"` // empty when the whole key is deleted
+}
 
 // structures for requests
 
@@ -54,18 +230,131 @@ type CleanRequest struct {
 	OptionID  string `json:"option_id"`
 }
 
+// Size bases a preview can headline
+const (
+	SizeApparent  = "apparent"  // bytes the files contain
+	SizeAllocated = "allocated" // bytes the files occupy on disk
+)
+
 // AnalyzeResponse - response for frontend
 type AnalyzeResponse struct {
-	TotalSize  uint64 		 `json:"total_size"`
-	TotalFiles uint64        `json:"total_files"`
-	Items      []AnalyzeItem `json:"items"`
+	// TotalSize is TotalApparentSize or TotalAllocatedSize, as SizeBasis says
+	TotalSize          uint64        `json:"total_size"`
+	SizeBasis          string        `json:"size_basis"`
+	TotalApparentSize  uint64        `json:"total_apparent_size"`
+	TotalAllocatedSize uint64        `json:"total_allocated_size"`
+	TotalFiles         uint64        `json:"total_files"`
+	TotalDirs          uint64        `json:"total_dirs"`
+	TotalShredBytes    uint64        `json:"total_shred_bytes,omitempty"`
+	Items              []AnalyzeItem `json:"items"`
+	// Overlap is what several items found, counted once in the totals
+	Overlap *FileTotals `json:"overlap,omitempty"`
+}
+
+// FileTotals - number of files and their bytes
+type FileTotals struct {
+	Size          uint64 `json:"size"`
+	AllocatedSize uint64 `json:"allocated_size"`
+	FileCount     uint64 `json:"file_count"`
 }
 
 // AnalyzeItem - certain item from analyzing
 type AnalyzeItem struct {
-	CleanerID string 	`json:"cleaner_id"`
-	OptionID  string 	`json:"option_id"`
-	Size      uint64 	`json:"size"`
-	FileCount uint64 	`json:"file_count"`
-	Paths     []string 	`json:"paths"`
+	CleanerID string `json:"cleaner_id"`
+	OptionID  string `json:"option_id"`
+	Size      uint64 `json:"size"` // apparent size
+	// AllocatedSize is what the files occupy on disk: less for sparse files,
+	// whole clusters for small ones
+	AllocatedSize uint64   `json:"allocated_size"`
+	FileCount     uint64   `json:"file_count"`
+	DirCount      uint64   `json:"dir_count"` // folders left empty that would be removed
+	Paths         []string `json:"paths"`
+	// Registry lists the keys and values registry actions would delete
+	Registry []RegistryEntry `json:"registry,omitempty"`
+	// Profiles breaks Size and FileCount down per application profile
+	Profiles []ProfileBreakdown `json:"profiles,omitempty"`
+	// Errors lists unknown IDs and paths that couldn't be inspected
+	Errors []ItemError `json:"errors,omitempty"`
+	// ErrorSummary counts the inaccessible files and folders per error kind
+	ErrorSummary []ErrorSummary `json:"error_summary,omitempty"`
+	// Duplicates are hard links and files matched by several actions of the
+	// option, counted once in Size and FileCount
+	Duplicates *FileTotals `json:"duplicates,omitempty"`
+	// ShredBytes is what "shred" actions write overwriting the files before
+	// deleting them, on top of the deletion itself
+	ShredBytes uint64 `json:"shred_bytes,omitempty"`
+	// ShredIneffective counts the files to shred whose data would likely
+	// survive the overwrite, e.g. on a copy-on-write file system
+	ShredIneffective uint64 `json:"shred_ineffective,omitempty"`
+}
+
+// ProfileBreakdown - share of an AnalyzeItem found in a single profile
+type ProfileBreakdown struct {
+	Profile   string `json:"profile"`
+	Name      string `json:"name,omitempty"`
+	Size      uint64 `json:"size"`
+	FileCount uint64 `json:"file_count"`
+}
+
+// CleanResponse - result of a clean for frontend
+type CleanResponse struct {
+	TotalSize   uint64      `json:"total_size"`
+	TotalFiles  uint64      `json:"total_files"`
+	TotalDirs   uint64      `json:"total_dirs"`
+	TotalFailed uint64      `json:"total_failed"`
+	Items       []CleanItem `json:"items"`
+	// RegistryBackup is the .reg file written before any registry entry was deleted
+	RegistryBackup string `json:"registry_backup,omitempty"`
+
+	// TotalShredded and TotalShredIneffective sum the items' Shredded and ShredIneffective
+	TotalShredded         uint64 `json:"total_shredded,omitempty"`
+	TotalShredIneffective uint64 `json:"total_shred_ineffective,omitempty"`
+}
+
+// CleanItem - what was removed for a certain cleaner option
+type CleanItem struct {
+	CleanerID string          `json:"cleaner_id"`
+	OptionID  string          `json:"option_id"`
+	Size      uint64          `json:"size"`       // bytes freed
+	FileCount uint64          `json:"file_count"` // files deleted or truncated
+	DirCount  uint64          `json:"dir_count"`  // empty folders removed
+	Failed    uint64          `json:"failed"`     // files and registry entries that couldn't be removed
+	Registry  []RegistryEntry `json:"registry,omitempty"`
+	Errors    []ItemError     `json:"errors,omitempty"`
+	// ErrorSummary counts files that couldn't be found or removed per error
+	// kind, and shredded files whose data likely survived as "shred_ineffective"
+	ErrorSummary []ErrorSummary `json:"error_summary,omitempty"`
+	// Shredded counts the files overwritten in place before deletion, and
+	// ShredIneffective those overwritten where the file system likely kept
+	// the old data, as far as it tells; both are included in FileCount
+	Shredded         uint64 `json:"shredded,omitempty"`
+	ShredIneffective uint64 `json:"shred_ineffective,omitempty"`
+}
+
+// WipeRequest - body of POST /api/wipe-free-space
+type WipeRequest struct {
+	Path string `json:"path"`           // any folder on the volume whose free space is wiped
+	Fill string `json:"fill,omitempty"` // "random" (default) or "zero"
+	// Margin is the free space left untouched; it can't go below the server's minimum
+	Margin ByteSize `json:"margin,omitempty"`
+}
+
+// WipeProgress - state of the running or last free space wipe
+type WipeProgress struct {
+	Running bool   `json:"running"`
+	Path    string `json:"path,omitempty"`
+	Target  uint64 `json:"target"`  // bytes to write
+	Written uint64 `json:"written"` // bytes written so far
+}
+
+// WipeResult - outcome of a free space wipe
+type WipeResult struct {
+	Path       string `json:"path"`
+	FreeBefore uint64 `json:"free_before"` // free space when the wipe started
+	Margin     uint64 `json:"margin"`      // free space left untouched
+	// Target is what was to be written, lowered if other programs used up
+	// free space meanwhile
+	Target  uint64 `json:"target"`
+	Written uint64 `json:"written"`
+	Files   int    `json:"files"` // temporary files written and deleted
 }
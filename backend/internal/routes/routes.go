@@ -9,4 +9,7 @@ const (
 	Preview     = "/preview"
 	Clean       = "/clean"
 	Abort       = "/abort"
+
+	// WipeFreeSpace starts a free space wipe (POST) and reports its progress (GET)
+	WipeFreeSpace = "/wipe-free-space"
 )
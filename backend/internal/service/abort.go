@@ -14,6 +14,10 @@ type AbortManager struct {
 
 var globalAbortManager = &AbortManager{}
 
+// globalWipeAbortManager holds the free space wipe, which runs for hours and
+// must not be cancelled by the previews and listings started meanwhile
+var globalWipeAbortManager = &AbortManager{}
+
 func (am *AbortManager) SetOperation(cancel context.CancelFunc) {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
@@ -56,3 +60,8 @@ func (am *AbortManager) IsRunning() bool {
 func GetAbortManager() *AbortManager {
 	return globalAbortManager
 }
+
+// GetWipeAbortManager returns the abort slot of the free space wipe
+func GetWipeAbortManager() *AbortManager {
+	return globalWipeAbortManager
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestAbortManager(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(am *AbortManager, cancel context.CancelFunc)
+		wantAborted bool
+	}{
+		{"no operation", func(*AbortManager, context.CancelFunc) {}, false},
+		{"running operation", func(am *AbortManager, cancel context.CancelFunc) { am.SetOperation(cancel) }, true},
+		{"cleared operation", func(am *AbortManager, cancel context.CancelFunc) {
+			am.SetOperation(cancel)
+			am.Clear()
+		}, false},
+		{"already aborted", func(am *AbortManager, cancel context.CancelFunc) {
+			am.SetOperation(cancel)
+			am.Abort()
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			am := &AbortManager{}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			test.setup(am, cancel)
+			if got := am.Abort(); got != test.wantAborted {
+				t.Errorf("Abort() = %v, want %v", got, test.wantAborted)
+			}
+			if test.wantAborted && ctx.Err() == nil {
+				t.Error("operation context not cancelled")
+			}
+			if am.IsRunning() {
+				t.Error("still running after Abort")
+			}
+		})
+	}
+}
+
+func TestAbortManagerReplacesOperation(t *testing.T) {
+	am := &AbortManager{}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	second, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+
+	am.SetOperation(cancelFirst)
+	am.SetOperation(cancelSecond)
+
+	if first.Err() == nil {
+		t.Error("starting a new operation didn't cancel the running one")
+	}
+	if second.Err() != nil || !am.IsRunning() {
+		t.Error("new operation isn't running")
+	}
+}
+
+func TestAbortManagerConcurrent(t *testing.T) {
+	am := &AbortManager{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			am.SetOperation(cancel)
+		}()
+		go func() {
+			defer wg.Done()
+			am.Abort()
+			am.IsRunning()
+		}()
+		go func() {
+			defer wg.Done()
+			am.Clear()
+		}()
+	}
+	wg.Wait()
+
+	am.Clear()
+	if am.IsRunning() || am.Abort() {
+		t.Error("operation left running after Clear")
+	}
+}
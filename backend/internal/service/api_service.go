@@ -3,15 +3,14 @@ package service
 import (
 	"backend/internal/cleaners"
 	"backend/internal/detector"
+	"backend/internal/filesystem"
 	"backend/internal/models"
 	"context"
 	"errors"
 	"io/fs"
-	"log"
 	"log/slog"
-	"os"
-	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -26,31 +25,86 @@ var workers = runtime.NumCPU()
 //
 // It loads all definitions via cleaners_util and organizes them for O(1) lookup
 // during the analysis phase.
+// Cleaners and options restricted to other platforms are left out.
+// Actions using the {{profile}} placeholder are expanded into one action per
+// profile discovered on this machine, and every action carries the cleaner's
+// process names so cleaning can tell when the app is running.
 // Returns a map keyed by [CleanerID][OptionID] containing the list of Actions.
 func LoadCleanerMap(ctx context.Context) (map[string]map[string][]models.Action, error) {
 	allCleaners, err := cleaners.LoadAllCleaners(ctx)
 	if err != nil {
-		slog.Error("Error loading all cleaners: %v\n", err)
+		slog.Error("Error loading all cleaners", "error", err)
 		return nil, err
 	}
 
 	cleanerMap := make(map[string]map[string][]models.Action)
 	for _, cleaner := range allCleaners {
+		if !cleaners.SupportsPlatform(cleaner.OS, cleaner.Arch) {
+			continue
+		}
+
+		var profiles []models.Profile
+		if cleaner.Profiles != nil {
+			profiles = detector.DiscoverProfiles(*cleaner.Profiles)
+		}
+
 		cleanerMap[cleaner.ID] = make(map[string][]models.Action)
 		for _, option := range cleaner.Options {
-			cleanerMap[cleaner.ID][option.ID] = option.Actions
+			if !cleaners.SupportsPlatform(option.OS, option.Arch) {
+				continue
+			}
+			actions := ExpandProfileActions(option.Actions, profiles)
+			for i := range actions {
+				actions[i].Processes = cleaner.Processes
+			}
+			cleanerMap[cleaner.ID][option.ID] = actions
 		}
 	}
 	return cleanerMap, nil
 }
 
+// ExpandProfileActions replaces every action whose path contains {{profile}}
+// with one copy per profile, tagged with that profile. Exclude patterns get the
+// profile path too; Include patterns match file names, so they are kept as is.
+// Other actions are kept as is.
+func ExpandProfileActions(actions []models.Action, profiles []models.Profile) []models.Action {
+	expanded := make([]models.Action, 0, len(actions))
+
+	for _, action := range actions {
+		if !strings.Contains(action.Path, detector.ProfilePlaceholder) {
+			expanded = append(expanded, action)
+			continue
+		}
+
+		for _, profile := range profiles {
+			replacer := strings.NewReplacer(detector.ProfilePlaceholder, profile.Path)
+
+			profileAction := action
+			profileAction.Path = replacer.Replace(action.Path)
+			profileAction.Exclude = make([]string, len(action.Exclude))
+			for i, exclude := range action.Exclude {
+				profileAction.Exclude[i] = replacer.Replace(exclude)
+			}
+			profileAction.Profile = &profile
+
+			expanded = append(expanded, profileAction)
+		}
+	}
+
+	return expanded
+}
+
 // AnalyzeRequests serves as the entry point for processing a batch of cleanup requests.
 //
 // It orchestrates the analysis by:
-// 1. Validating that the requested CleanerID and OptionID exist.
+// 1. Validating that the requested CleanerID and OptionID exist; unknown ones
+// are answered with an item carrying an unknown_cleaner or unknown_option error.
 // 2. Spinning up concurrent workers (limited by the 'workers' global) to process requests.
 // 3. Aggregating the results (Size, FileCount) into a single response.
-func AnalyzeRequests(ctx context.Context,requests []models.CleanRequest,
+//
+// A file found by several items counts towards each of them, but only once
+// towards the totals; what the items share is reported as the overlap.
+func AnalyzeRequests(ctx context.Context, requests []models.CleanRequest,
 	cleanerMap map[string]map[string][]models.Action) (*models.AnalyzeResponse, error) {
 	response := &models.AnalyzeResponse{
 		Items: make([]models.AnalyzeItem, 0),
@@ -59,25 +113,32 @@ func AnalyzeRequests(ctx context.Context,requests []models.CleanRequest,
 	semaphore := make(chan struct{}, workers)
 	var wg sync.WaitGroup
 	resultsChan := make(chan models.AnalyzeItem, len(requests))
+	counted := NewFileSet()
 
 	for _, request := range requests {
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 
-		actions, ok := cleanerMap[request.CleanerID][request.OptionID]
-		if !ok {
+		if itemError, unknown := unknownIDError(request, cleanerMap); unknown {
+			resultsChan <- models.AnalyzeItem{
+				CleanerID: request.CleanerID,
+				OptionID:  request.OptionID,
+				Paths:     []string{},
+				Errors:    []models.ItemError{itemError},
+			}
 			continue
 		}
+		actions := cleanerMap[request.CleanerID][request.OptionID]
 
 		wg.Add(1)
 		select {
 		case semaphore <- struct{}{}:
 			go func(request models.CleanRequest, actions []models.Action) {
-				defer wg.Done() // decrease the counter when the goroutine completes
+				defer wg.Done()                // decrease the counter when the goroutine completes
 				defer func() { <-semaphore }() // clear the semaphore slot when done
 
-				item, err := AnalyzeActions(ctx, request, actions)
+				item, err := analyzeActions(ctx, request, actions, counted)
 				if err != nil {
 					return
 				}
@@ -87,7 +148,7 @@ func AnalyzeRequests(ctx context.Context,requests []models.CleanRequest,
 				case <-ctx.Done():
 					return
 				}
-			} (request, actions)
+			}(request, actions)
 		case <-ctx.Done():
 			wg.Done()
 			return nil, ctx.Err()
@@ -102,25 +163,52 @@ func AnalyzeRequests(ctx context.Context,requests []models.CleanRequest,
 
 	for item := range resultsChan {
 		response.Items = append(response.Items, item)
-		response.TotalSize += item.Size
+		response.TotalApparentSize += item.Size
+		response.TotalAllocatedSize += item.AllocatedSize
 		response.TotalFiles += item.FileCount
+		response.TotalDirs += item.DirCount
+		response.TotalShredBytes += item.ShredBytes
 	}
 
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
 
+	if overlap := counted.Repeated(); overlap != nil {
+		response.Overlap = overlap
+		response.TotalApparentSize -= overlap.Size
+		response.TotalAllocatedSize -= overlap.AllocatedSize
+		response.TotalFiles -= overlap.FileCount
+	}
+	SetSizeBasis(response, models.SizeApparent)
+
 	return response, nil
 }
 
 // AnalyzeActions processes the specific actions (paths/globs) associated with a single cleaner option.
 //
 // It checks OS compatibility for each action and executes them concurrently using
-// the same worker-pool pattern as AnalyzeRequests.
+// the same worker-pool pattern as AnalyzeRequests. Registry actions contribute
+// the keys and values they would delete instead of files. Hard links and files
+// matched by several actions are counted once.
 func AnalyzeActions(ctx context.Context, request models.CleanRequest, actions []models.Action) (models.AnalyzeItem, error) {
+	return analyzeActions(ctx, request, actions, nil)
+}
+
+// analyzeActions is AnalyzeActions adding every file it counts to job, the
+// set shared by all items of a request
+func analyzeActions(ctx context.Context, request models.CleanRequest, actions []models.Action,
+	job *FileSet) (models.AnalyzeItem, error) {
 	var size uint64 = 0
+	var allocatedSize uint64 = 0
 	var fileCount uint64 = 0
+	var dirCount uint64 = 0
+	var shredBytes, shredIneffective uint64
 	var foundPaths []string
+	var registryEntries []models.RegistryEntry
+	var itemErrors []models.ItemError
+	var errorSummary []models.ErrorSummary
+	counted := NewFileSet()
 
 	semaphore := make(chan struct{}, workers)
 	var wg sync.WaitGroup
@@ -142,12 +230,11 @@ func AnalyzeActions(ctx context.Context, request models.CleanRequest, actions []
 				defer wg.Done()
 				defer func() { <-semaphore }()
 
-				actionSize, actionCount, actionPaths := ProcessAction(ctx, action)
-
-				result := models.ActionResult{
-					Size:      actionSize,
-					FileCount: actionCount,
-					Paths:     actionPaths,
+				result := models.ActionResult{Profile: action.Profile}
+				if IsRegistryCommand(action.Command) {
+					result.Registry = RegistryTargets(action)
+				} else {
+					result = ProcessAction(ctx, action, counted, job)
 				}
 
 				select {
@@ -168,10 +255,30 @@ func AnalyzeActions(ctx context.Context, request models.CleanRequest, actions []
 		close(semaphore)
 	}()
 
+	profiles := make(map[string]*models.ProfileBreakdown)
 	for result := range resultChan {
 		size += result.Size
+		allocatedSize += result.AllocatedSize
+		shredBytes += result.ShredBytes
+		shredIneffective += result.ShredIneffective
+		dirCount += result.DirCount
 		fileCount += result.FileCount
 		foundPaths = append(foundPaths, result.Paths...)
+		registryEntries = append(registryEntries, result.Registry...)
+		if room := maxErrorsPerItem - len(itemErrors); room > 0 {
+			itemErrors = append(itemErrors, result.Errors[:min(room, len(result.Errors))]...)
+		}
+		errorSummary = mergeSummaries(errorSummary, result.Summary)
+
+		if result.Profile != nil {
+			breakdown, ok := profiles[result.Profile.ID]
+			if !ok {
+				breakdown = &models.ProfileBreakdown{Profile: result.Profile.ID, Name: result.Profile.Name}
+				profiles[result.Profile.ID] = breakdown
+			}
+			breakdown.Size += result.Size
+			breakdown.FileCount += result.FileCount
+		}
 	}
 
 	if ctx.Err() != nil {
@@ -179,105 +286,254 @@ func AnalyzeActions(ctx context.Context, request models.CleanRequest, actions []
 	}
 
 	return models.AnalyzeItem{
-		CleanerID: request.CleanerID,
-		OptionID:  request.OptionID,
-		Size:      size,
-		FileCount: fileCount,
-		Paths:     foundPaths,
+		CleanerID:        request.CleanerID,
+		OptionID:         request.OptionID,
+		Size:             size,
+		AllocatedSize:    allocatedSize,
+		FileCount:        fileCount,
+		DirCount:         dirCount,
+		Paths:            foundPaths,
+		Registry:         registryEntries,
+		Profiles:         sortedProfileBreakdown(profiles),
+		Errors:           itemErrors,
+		ErrorSummary:     errorSummary,
+		Duplicates:       counted.Repeated(),
+		ShredBytes:       shredBytes,
+		ShredIneffective: shredIneffective,
 	}, nil
 }
 
-// ProcessAction acts as a router to determine the correct file discovery strategy.
+// SetSizeBasis makes the response headline the apparent or allocated total. It
+// returns false, leaving the response unchanged, for an unknown basis.
+func SetSizeBasis(response *models.AnalyzeResponse, basis string) bool {
+	switch basis {
+	case models.SizeApparent:
+		response.TotalSize = response.TotalApparentSize
+	case models.SizeAllocated:
+		response.TotalSize = response.TotalAllocatedSize
+	default:
+		return false
+	}
+	response.SizeBasis = basis
+	return true
+}
+
+// sortedProfileBreakdown flattens the per-profile totals, ordered by profile ID
+func sortedProfileBreakdown(profiles map[string]*models.ProfileBreakdown) []models.ProfileBreakdown {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	breakdown := make([]models.ProfileBreakdown, 0, len(profiles))
+	for _, profile := range profiles {
+		breakdown = append(breakdown, *profile)
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Profile < breakdown[j].Profile })
+	return breakdown
+}
+
+// Visitor receives what file discovery finds. Discovery calls it from several
+// goroutines at once, so both functions must be safe for concurrent use.
+type Visitor struct {
+	File  func(path string, info fs.FileInfo)
+	Error func(path string, err error) // optional; files and folders that don't exist are not reported
+}
+
+// fail reports a discovery error to the visitor
+func (v Visitor) fail(path string, err error) {
+	if v.Error == nil || errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	v.Error(path, err)
+}
+
+// ProcessAction finds the files an action matches and returns their total
+// size, their count, up to maxPathsToCollect of their paths and up to
+// maxErrorsPerItem errors met on the way. Folders the cleaning would leave
+// empty and remove are counted too.
+//
+// Files already in item are skipped; the others are added to item and job.
+// Either set may be nil.
+func ProcessAction(ctx context.Context, action models.Action, item *FileSet, job *FileSet) models.ActionResult {
+	result := models.ActionResult{Profile: action.Profile}
+	var errs errorCollector
+	var mutex sync.Mutex
+
+	// the files found, so the folders they leave empty can be counted
+	var matched map[string]bool
+	if removesDirs(action) {
+		matched = make(map[string]bool)
+	}
+
+	VisitActionFiles(ctx, action, Visitor{
+		File: func(path string, info fs.FileInfo) {
+			if matched != nil {
+				mutex.Lock()
+				matched[path] = true
+				mutex.Unlock()
+			}
+
+			id := filesystem.Identify(path, info)
+			size, allocated := uint64(info.Size()), filesystem.AllocatedSize(path, info)
+			if !item.AddID(id, size, allocated) {
+				return
+			}
+			job.AddID(id, size, allocated)
+
+			var shredBytes uint64
+			var ineffective string
+			if action.Command == "shred" {
+				shredBytes, ineffective = shredCost(action, path, info)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			result.Size += size
+			result.AllocatedSize += allocated
+			result.FileCount++
+			result.ShredBytes += shredBytes
+			if ineffective != "" {
+				result.ShredIneffective++
+			}
+			if len(result.Paths) < maxPathsToCollect {
+				result.Paths = append(result.Paths, path)
+			}
+		},
+		Error: errs.add,
+	})
+
+	VisitEmptyDirs(ctx, action, func(path string) bool { return matched[path] }, func(string) bool {
+		result.DirCount++
+		return true
+	})
+
+	result.Errors = errs.list()
+	result.Summary = errs.summarize()
+	return result
+}
+
+// VisitActionFiles acts as a router to determine the correct file discovery strategy.
 //
 // It expands environment variables in paths (e.g., %APPDATA%) and selects between:
-// - Globbing (if "*" is present or explicitly set)
-// - Recursive walking ("walk.files")
+// - Recursive walking of every directory matching a wildcard root ("walk.files" or "walk.all" with glob syntax)
+// - Globbing (if the path has glob syntax - *, ?, [...] or {a,b} - or it is explicitly set)
+// - Recursive walking ("walk.files" or "walk.all")
+// - Folders matched as a whole and everything in them ("walk.dirs" or "walk.top")
 // - Single file verification
-func ProcessAction(ctx context.Context, action models.Action) (uint64, uint64, []string) {
-	if ctx.Err() != nil {
-		return 0, 0, nil
+//
+// The action's Include, Regex and Exclude filters and its size limits are
+// applied by every strategy. With retention rules (keep_newest, keep_total_size)
+// every match is collected with its metadata first and only the files beyond
+// the limits are visited, newest first. Both preview and cleaning discover
+// files through here, so they see the same files.
+// Registry actions match no files, nor do "vacuum" actions, which cleaning skips.
+func VisitActionFiles(ctx context.Context, action models.Action, visit Visitor) {
+	if ctx.Err() != nil || IsRegistryCommand(action.Command) || action.Command == "vacuum" {
+		return
 	}
 
 	searchPath := detector.ExpandPath(action.Path)
+	filter := NewPathFilter(action)
 
-	if action.Search == "glob" || strings.Contains(searchPath, "*") {
-		return ProcessGlobAction(ctx, searchPath)
-	} else if action.Search == "walk.files" {
-		return ProcessWalkAction(ctx, searchPath)
+	keep := newRetention(action)
+	if keep == nil {
+		discoverFiles(ctx, action.Search, searchPath, filter, filter.sized(visit))
+		return
 	}
 
-	return ProcessFileAction(searchPath)
+	discoverFiles(ctx, action.Search, searchPath, filter, filter.sized(Visitor{File: keep.add, Error: visit.Error}))
+	for _, file := range keep.excess() {
+		if ctx.Err() != nil {
+			return
+		}
+		visit.File(file.path, file.info)
+	}
 }
 
-// ProcessGlobAction handles file discovery using standard filesystem glob patterns.
-//
-// It performs a concurrent stat() on all matches found by filepath.Glob.
-// Thread-safe: Uses a mutex to safely update the size, count, and path slice.
-func ProcessGlobAction(ctx context.Context, searchPath string) (uint64, uint64, []string) {
-	var size uint64 = 0
-	var fileCount uint64 = 0
-	var paths []string
+// discoverFiles runs the discovery strategy of a search, see VisitActionFiles
+func discoverFiles(ctx context.Context, search string, searchPath string, filter PathFilter, visit Visitor) {
+	if IsDirSearch(search) {
+		ProcessDirsAction(ctx, search, searchPath, filter, visit)
+	} else if IsWalkSearch(search) && filesystem.HasMeta(searchPath) {
+		ProcessWalkGlobAction(ctx, searchPath, filter, visit)
+	} else if search == "glob" || filesystem.HasMeta(searchPath) {
+		ProcessGlobAction(ctx, searchPath, filter, visit)
+	} else if IsWalkSearch(search) {
+		ProcessWalkAction(ctx, searchPath, filter, visit)
+	} else {
+		ProcessFileAction(searchPath, filter, visit)
+	}
+}
 
-	matches, err := filepath.Glob(searchPath)
-	if err != nil {
-		log.Printf("Error in glob %s: %v\n", searchPath, err)
-		return 0, 0, nil
+// ProcessWalkGlobAction walks every directory matched by a wildcard root
+// (e.g. `%LocalAppData%\Google\Chrome*\User Data`).
+func ProcessWalkGlobAction(ctx context.Context, searchPath string, filter PathFilter, visit Visitor) {
+	for _, root := range walkRoots(searchPath, visit) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		ProcessWalkAction(ctx, root, filter, visit)
 	}
+}
 
-	slog.Info("Processing glob", "path", searchPath, "matches", len(matches))
+// ProcessGlobAction handles file discovery using glob patterns, including
+// "**" and {a,b} alternatives (see filesystem.WalkGlob).
+//
+// Matches are stat'ed concurrently as the glob finds them, so a pattern over a
+// large tree starts producing files before the tree has been read.
+func ProcessGlobAction(ctx context.Context, searchPath string, filter PathFilter, visit Visitor) {
+	fsys := filesystem.Current()
 
 	var wg sync.WaitGroup
-	var mutex sync.Mutex
 	semaphore := make(chan struct{}, workers)
+	matches := 0
 
-	cancelled := false
-	for _, match := range matches {
-		select {
-		case <-ctx.Done():
-			cancelled = true
-		default:
-		}
-
-		if cancelled {
-			break
+	err := filesystem.WalkGlob(fsys, searchPath, func(match string) error {
+		if !filter.AllowsFile(match) {
+			return nil
 		}
-
-		wg.Add(1)
+		matches++
 
 		select {
 		case semaphore <- struct{}{}:
-			go func(match string) {
-				defer wg.Done()
-				defer func() { <-semaphore }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 
-				if ctx.Err() != nil {
-					return
-				}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
 
-				info, err := os.Stat(match)
-				if err != nil || info.IsDir() {
-					return
-				}
+			if ctx.Err() != nil {
+				return
+			}
 
-				mutex.Lock()
-				size += uint64(info.Size())
-				fileCount++
-				if len(paths) < maxPathsToCollect {
-					paths = append(paths, match)
-				}
-				mutex.Unlock()
+			info, err := fsys.Stat(match)
+			if err != nil {
+				visit.fail(match, err)
+				return
+			}
+			if info.IsDir() {
+				return
+			}
 
-			}(match)
-		case <-ctx.Done():
-			wg.Done()
-			break
-		}
-	}
+			visit.File(match, info)
+		}()
+		return nil
+	})
 
 	wg.Wait()
 	close(semaphore)
 
-	return size, fileCount, paths
+	if err != nil && !errors.Is(err, ctx.Err()) {
+		slog.Error("Error in glob", "path", searchPath, "error", err)
+		visit.fail(searchPath, err)
+		return
+	}
+	slog.Info("Processed glob", "path", searchPath, "matches", matches)
 }
 
 // ProcessWalkAction handles recursive directory traversal.
@@ -285,35 +541,29 @@ func ProcessGlobAction(ctx context.Context, searchPath string) (uint64, uint64,
 // It employs a producer-consumer pattern:
 // - CollectFilePaths (Producer): Walks the dir and pushes paths to a channel.
 // - ProcessFileWorker (Consumers): 'workers' amount of goroutines read from the channel and stat files.
-func ProcessWalkAction(ctx context.Context, searchPath string) (uint64, uint64, []string) {
-	var size, fileCount uint64
-	var paths []string
-
+func ProcessWalkAction(ctx context.Context, searchPath string, filter PathFilter, visit Visitor) {
 	var wg sync.WaitGroup
-	var mutex sync.Mutex
 	fileChan := make(chan string, 100)
 
 	// start worker goroutines
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go ProcessFileWorker(ctx, fileChan, &size, &fileCount, &paths, &mutex, &wg)
+		go ProcessFileWorker(ctx, fileChan, visit, &wg)
 	}
 
-	CollectFilePaths(ctx, searchPath, fileChan)
+	CollectFilePaths(ctx, searchPath, filter, fileChan, visit)
 
 	close(fileChan)
 	wg.Wait()
-
-	return size, fileCount, paths
 }
 
 // ProcessFileWorker is the consumer for ProcessWalkAction.
-// It reads file paths from a channel, calculates their size, and aggregates results.
-func ProcessFileWorker(ctx context.Context, fileChan chan string, size *uint64, fileCount *uint64,
-	paths *[]string, mutex *sync.Mutex, wg *sync.WaitGroup) {
-
+// It reads file paths from a channel, stats them and passes regular files to visit.
+func ProcessFileWorker(ctx context.Context, fileChan chan string, visit Visitor, wg *sync.WaitGroup) {
 	defer wg.Done()
 
+	fsys := filesystem.Current()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -323,35 +573,44 @@ func ProcessFileWorker(ctx context.Context, fileChan chan string, size *uint64,
 				return
 			}
 
-			info, err := os.Stat(path)
-			if err != nil || info.IsDir() {
+			info, err := fsys.Stat(path)
+			if err != nil {
+				visit.fail(path, err)
 				continue
 			}
-
-			mutex.Lock()
-			*size += uint64(info.Size())
-			*fileCount++
-			if len(*paths) < maxPathsToCollect {
-				*paths = append(*paths, path)
+			if info.IsDir() {
+				continue
 			}
-			mutex.Unlock()
+
+			visit.File(path, info)
 		}
 	}
 }
 
 // CollectFilePaths is the producer for ProcessWalkAction.
-// It walks the directory tree and sends valid file paths to the fileChan.
-func CollectFilePaths(ctx context.Context, searchPath string, fileChan chan string) {
-	err := filepath.WalkDir(searchPath, func(path string, d fs.DirEntry, err error) error {
+// It walks the directory tree and sends valid file paths to the fileChan,
+// skipping excluded directories and those beyond the filter's depth entirely
+// and files the filter doesn't allow.
+// Folders that can't be read are reported to visit and skipped.
+func CollectFilePaths(ctx context.Context, searchPath string, filter PathFilter, fileChan chan string, visit Visitor) {
+	err := filesystem.WalkDir(filesystem.Current(), searchPath, func(path string, d fs.DirEntry, err error) error {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
 		if err != nil {
+			visit.fail(path, err)
 			return nil
 		}
 
 		if d.IsDir() {
+			if filter.Excludes(path) || !filter.Descends(walkDepth(searchPath, path)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !filter.AllowsFile(path) {
 			return nil
 		}
 
@@ -364,16 +623,24 @@ func CollectFilePaths(ctx context.Context, searchPath string, fileChan chan stri
 	})
 
 	if err != nil && !errors.Is(ctx.Err(), err) {
-		slog.Error("Error walking directory %s: %v\n", searchPath, err)
+		slog.Error("Error walking directory", "path", searchPath, "error", err)
 	}
 }
 
 // ProcessFileAction handles the simplest case: verifying a single specific file path.
-func ProcessFileAction(searchPath string) (uint64, uint64, []string) {
-	info, err := os.Stat(searchPath)
-	if err != nil || info.IsDir() {
-		return 0, 0, nil
+func ProcessFileAction(searchPath string, filter PathFilter, visit Visitor) {
+	if !filter.AllowsFile(searchPath) {
+		return
+	}
+
+	info, err := filesystem.Current().Stat(searchPath)
+	if err != nil {
+		visit.fail(searchPath, err)
+		return
+	}
+	if info.IsDir() {
+		return
 	}
 
-	return uint64(info.Size()), 1, []string{searchPath}
+	visit.File(searchPath, info)
 }
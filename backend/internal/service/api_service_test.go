@@ -0,0 +1,509 @@
+package service
+
+import (
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProcessWalkAction(t *testing.T) {
+	memory := useMemoryFS(t)
+	buildTree(memory, testPath("cache"), 40, 10, 3)
+	memory.WriteFile(testPath("cache", "keep", "a.log"), []byte("12345"))
+
+	tests := []struct {
+		name      string
+		action    models.Action
+		wantSize  uint64
+		wantFiles uint64
+	}{
+		{"every file", models.Action{Path: testPath("cache")}, 40*3 + 5, 41},
+		{"include pattern", models.Action{Path: testPath("cache"), Include: []string{"*.log"}}, 10*3 + 5, 11},
+		{"excluded folder", models.Action{Path: testPath("cache"), Exclude: []string{testPath("cache", "keep")}}, 40 * 3, 40},
+		{"excluded pattern", models.Action{Path: testPath("cache"), Exclude: []string{testPath("cache", "d0000*")}}, 5, 1},
+		{"missing root", models.Action{Path: testPath("missing")}, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var size, files atomic.Uint64
+			ProcessWalkAction(context.Background(), test.action.Path, NewPathFilter(test.action), Visitor{File: func(_ string, info fs.FileInfo) {
+				size.Add(uint64(info.Size()))
+				files.Add(1)
+			}})
+
+			if size.Load() != test.wantSize || files.Load() != test.wantFiles {
+				t.Errorf("got %d bytes in %d files, want %d bytes in %d files",
+					size.Load(), files.Load(), test.wantSize, test.wantFiles)
+			}
+		})
+	}
+}
+
+func TestProcessActionCapsCollectedPaths(t *testing.T) {
+	memory := useMemoryFS(t)
+	buildTree(memory, testPath("big"), 2*maxPathsToCollect, 100, 1)
+
+	result := ProcessAction(context.Background(), models.Action{Search: "walk.files", Path: testPath("big")}, nil, nil)
+
+	if result.FileCount != 2*maxPathsToCollect || result.Size != 2*maxPathsToCollect {
+		t.Errorf("got %d bytes in %d files, want %d in %d", result.Size, result.FileCount, 2*maxPathsToCollect, 2*maxPathsToCollect)
+	}
+	if len(result.Paths) != maxPathsToCollect {
+		t.Errorf("collected %d paths, want %d", len(result.Paths), maxPathsToCollect)
+	}
+}
+
+func TestProcessActionStrategies(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("one", "file.txt"), []byte("1234"))
+	memory.WriteFile(testPath("one", "sub", "deep.txt"), []byte("12"))
+	memory.WriteFile(testPath("two", "sub", "deep.txt"), []byte("123"))
+	memory.Symlink(testPath("one", "file.txt"), testPath("links", "file.txt"))
+	memory.Symlink(testPath("two"), testPath("links", "dir"))
+
+	tests := []struct {
+		name      string
+		action    models.Action
+		wantFiles []string
+	}{
+		{"single file", models.Action{Search: "file", Path: testPath("one", "file.txt")}, []string{testPath("one", "file.txt")}},
+		{"single folder is not a file", models.Action{Search: "file", Path: testPath("one")}, nil},
+		{"glob skips folders", models.Action{Search: "glob", Path: testPath("one", "*")}, []string{testPath("one", "file.txt")}},
+		{"doublestar glob", models.Action{Search: "glob", Path: testPath("**", "deep.txt")}, []string{testPath("one", "sub", "deep.txt"), testPath("two", "sub", "deep.txt")}},
+		{"brace glob", models.Action{Search: "glob", Path: testPath("{one,two}", "*", "*.txt")}, []string{testPath("one", "sub", "deep.txt"), testPath("two", "sub", "deep.txt")}},
+		{"brace alternatives without a search", models.Action{Path: testPath("{one,two}", "sub", "deep.txt")}, []string{testPath("one", "sub", "deep.txt"), testPath("two", "sub", "deep.txt")}},
+		{"question mark in a file search", models.Action{Search: "file", Path: testPath("one", "fil?.txt")}, []string{testPath("one", "file.txt")}},
+		{"walk", models.Action{Search: "walk.files", Path: testPath("one")}, []string{testPath("one", "file.txt"), testPath("one", "sub", "deep.txt")}},
+		{"walk class roots", models.Action{Search: "walk.files", Path: testPath("[ot][nw]?", "sub")}, []string{testPath("one", "sub", "deep.txt"), testPath("two", "sub", "deep.txt")}},
+		{"walk wildcard roots", models.Action{Search: "walk.files", Path: testPath("*", "sub")}, []string{testPath("one", "sub", "deep.txt"), testPath("two", "sub", "deep.txt")}},
+		{"walk does not follow folder links", models.Action{Search: "walk.files", Path: testPath("links")}, []string{testPath("links", "file.txt")}},
+		{"registry actions match no files", models.Action{Command: models.RegistryDeleteKey, Path: testPath("one")}, nil},
+		{"vacuum actions match no files", models.Action{Command: "vacuum", Search: "file", Path: testPath("one", "file.txt")}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paths := ProcessAction(context.Background(), test.action, nil, nil).Paths
+			sort.Strings(paths)
+
+			if len(paths) != len(test.wantFiles) {
+				t.Fatalf("got %v, want %v", paths, test.wantFiles)
+			}
+			for i := range paths {
+				if paths[i] != test.wantFiles[i] {
+					t.Fatalf("got %v, want %v", paths, test.wantFiles)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessWalkActionCancelledMidWalk(t *testing.T) {
+	memory := newTree(100, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var listed atomic.Int32
+	useFS(t, hookFS{Memory: memory, onReadDir: func(string) {
+		if listed.Add(1) == 5 {
+			cancel()
+		}
+	}})
+
+	files := ProcessAction(ctx, models.Action{Search: "walk.files", Path: root}, nil, nil).FileCount
+
+	if files >= 1000 {
+		t.Errorf("walk visited all %d files after cancellation", files)
+	}
+	if n := listed.Load(); n > 6 {
+		t.Errorf("walk listed %d folders after cancellation at the 5th", n)
+	}
+}
+
+// deniedFS refuses to list the folder at denied
+type deniedFS struct {
+	*filesystem.Memory
+	denied string
+}
+
+func (d deniedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == d.denied {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return d.Memory.ReadDir(name)
+}
+
+func TestProcessActionErrors(t *testing.T) {
+	memory := filesystem.NewMemory()
+	memory.WriteFile(testPath("open", "a.tmp"), []byte("12"))
+	memory.WriteFile(testPath("locked", "b.tmp"), []byte("12"))
+	useFS(t, deniedFS{Memory: memory, denied: testPath("locked")})
+
+	tests := []struct {
+		name      string
+		action    models.Action
+		wantFiles uint64
+		wantKinds []string
+	}{
+		{"permission denied", models.Action{Search: "walk.files", Path: root}, 1, []string{ErrorPermissionDenied}},
+		{"glob syntax", models.Action{Search: "glob", Path: testPath("[")}, 0, []string{ErrorGlobSyntax}},
+		{"missing paths are not errors", models.Action{Search: "walk.files", Path: testPath("missing")}, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ProcessAction(context.Background(), test.action, nil, nil)
+
+			var kinds []string
+			for _, itemError := range result.Errors {
+				kinds = append(kinds, itemError.Kind)
+			}
+			if result.FileCount != test.wantFiles || len(kinds) != len(test.wantKinds) {
+				t.Fatalf("got %d files and errors %+v, want %d files and %v", result.FileCount, result.Errors, test.wantFiles, test.wantKinds)
+			}
+			for i := range kinds {
+				if kinds[i] != test.wantKinds[i] {
+					t.Errorf("got errors %v, want %v", kinds, test.wantKinds)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeActionsSummarizesErrors(t *testing.T) {
+	memory := filesystem.NewMemory()
+	memory.WriteFile(testPath("open", "a.tmp"), []byte("12"))
+	memory.WriteFile(testPath("locked", "b.tmp"), []byte("12"))
+	useFS(t, deniedFS{Memory: memory, denied: testPath("locked")})
+
+	actions := []models.Action{
+		{Search: "walk.files", Path: testPath("open")},
+		{Search: "walk.files", Path: testPath("locked")},
+		{Search: "glob", Path: testPath("[")},
+	}
+	item, err := AnalyzeActions(context.Background(), models.CleanRequest{CleanerID: "app", OptionID: "all"}, actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]uint64)
+	for _, group := range item.ErrorSummary {
+		counts[group.Kind] = group.Count
+	}
+	if item.FileCount != 1 || counts[ErrorPermissionDenied] != 1 || counts[ErrorGlobSyntax] != 1 || len(counts) != 2 {
+		t.Errorf("got %d files and summary %+v", item.FileCount, item.ErrorSummary)
+	}
+}
+
+func TestAnalyzeRequests(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("a", "1.tmp"), []byte("1234"))
+	memory.WriteFile(testPath("b", "1.tmp"), []byte("12"))
+
+	cleanerMap := map[string]map[string][]models.Action{
+		"app": {
+			"a":    {{Search: "walk.files", Path: testPath("a")}},
+			"b":    {{Search: "walk.files", Path: testPath("b")}},
+			"both": {{Search: "walk.files", Path: testPath("a")}, {Search: "glob", Path: testPath("b", "*")}},
+			"none": {{Search: "walk.files", Path: testPath("a"), OS: []string{"no-such-os"}}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		requests  []models.CleanRequest
+		wantItems int
+		wantSize  uint64
+		wantFiles uint64
+	}{
+		{"one option", []models.CleanRequest{{CleanerID: "app", OptionID: "a"}}, 1, 4, 1},
+		{"several options", []models.CleanRequest{{CleanerID: "app", OptionID: "a"}, {CleanerID: "app", OptionID: "b"}}, 2, 6, 2},
+		{"several actions", []models.CleanRequest{{CleanerID: "app", OptionID: "both"}}, 1, 6, 2},
+		{"unsupported os", []models.CleanRequest{{CleanerID: "app", OptionID: "none"}}, 1, 0, 0},
+		{"unknown cleaner", []models.CleanRequest{{CleanerID: "missing", OptionID: "a"}}, 1, 0, 0},
+		{"unknown option", []models.CleanRequest{{CleanerID: "app", OptionID: "missing"}}, 1, 0, 0},
+		{"unknown among known", []models.CleanRequest{{CleanerID: "app", OptionID: "missing"}, {CleanerID: "app", OptionID: "b"}}, 2, 2, 1},
+		{"no requests", nil, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := AnalyzeRequests(context.Background(), test.requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(response.Items) != test.wantItems || response.TotalSize != test.wantSize || response.TotalFiles != test.wantFiles {
+				t.Errorf("got %d items, %d bytes, %d files; want %d items, %d bytes, %d files",
+					len(response.Items), response.TotalSize, response.TotalFiles, test.wantItems, test.wantSize, test.wantFiles)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRequestsDeduplicates(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("data", "cache", "a.tmp"), []byte("1234"))
+	memory.WriteFile(testPath("data", "b.tmp"), []byte("12"))
+	if err := memory.Link(testPath("data", "b.tmp"), testPath("data", "c.tmp")); err != nil {
+		t.Fatal(err)
+	}
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {
+		"cache": {{Search: "walk.files", Path: testPath("data", "cache")}},
+		"all":   {{Search: "walk.files", Path: testPath("data")}, {Search: "glob", Path: testPath("data", "*.tmp")}},
+	}}
+	requests := []models.CleanRequest{{CleanerID: "app", OptionID: "cache"}, {CleanerID: "app", OptionID: "all"}}
+
+	response, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.TotalSize != 6 || response.TotalFiles != 2 || response.TotalAllocatedSize != 2*filesystem.DefaultClusterSize {
+		t.Errorf("got %d bytes (%d allocated) in %d files, want 6 (%d) in 2",
+			response.TotalSize, response.TotalAllocatedSize, response.TotalFiles, 2*filesystem.DefaultClusterSize)
+	}
+	if response.Overlap == nil || response.Overlap.Size != 4 || response.Overlap.FileCount != 1 {
+		t.Errorf("got overlap %+v, want a.tmp", response.Overlap)
+	}
+	for _, item := range response.Items {
+		if item.OptionID != "all" {
+			continue
+		}
+		// b.tmp and its hard link c.tmp are found by both actions
+		if item.Size != 6 || item.FileCount != 2 || item.Duplicates == nil || item.Duplicates.FileCount != 3 {
+			t.Errorf("got %d bytes in %d files with duplicates %+v", item.Size, item.FileCount, item.Duplicates)
+		}
+	}
+}
+
+func TestAnalyzeRequestsConcurrent(t *testing.T) {
+	memory := useMemoryFS(t)
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {}}
+	var requests []models.CleanRequest
+	for i := 0; i < 4*runtime.NumCPU(); i++ {
+		option := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		buildTree(memory, testPath(option), 50, 10, 2)
+		cleanerMap["app"][option] = []models.Action{{Search: "walk.files", Path: testPath(option)}}
+		requests = append(requests, models.CleanRequest{CleanerID: "app", OptionID: option})
+	}
+
+	response, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Items) != len(requests) || response.TotalFiles != uint64(50*len(requests)) {
+		t.Errorf("got %d items with %d files, want %d items with %d files",
+			len(response.Items), response.TotalFiles, len(requests), 50*len(requests))
+	}
+}
+
+func TestAnalyzeRequestsCancelled(t *testing.T) {
+	memory := useMemoryFS(t)
+	buildTree(memory, root, 10, 5, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {"all": {{Search: "walk.files", Path: root}}}}
+	response, err := AnalyzeRequests(ctx, []models.CleanRequest{{CleanerID: "app", OptionID: "all"}}, cleanerMap)
+
+	if !errors.Is(err, context.Canceled) || response != nil {
+		t.Errorf("got %v, %v; want nil, context.Canceled", response, err)
+	}
+}
+
+func TestAnalyzeRequestsTimeout(t *testing.T) {
+	memory := newTree(200, 2)
+	useFS(t, hookFS{Memory: memory, onReadDir: func(string) { time.Sleep(5 * time.Millisecond) }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {"all": {{Search: "walk.files", Path: root}}}}
+	start := time.Now()
+	_, err := AnalyzeRequests(ctx, []models.CleanRequest{{CleanerID: "app", OptionID: "all"}}, cleanerMap)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("analysis took %v after a 20ms timeout", elapsed)
+	}
+}
+
+func TestAnalyzeRequestsLargeTree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("large tree")
+	}
+
+	const files = 50_000
+	memory := useMemoryFS(t)
+	buildTree(memory, root, files, 500, 1)
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {
+		"all":  {{Search: "walk.files", Path: root}},
+		"logs": {{Search: "walk.files", Path: root, Include: []string{"*.log"}}},
+	}}
+	requests := []models.CleanRequest{{CleanerID: "app", OptionID: "all"}, {CleanerID: "app", OptionID: "logs"}}
+
+	response, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every log is found by both options but counted once
+	if response.TotalFiles != files || response.Overlap == nil || response.Overlap.FileCount != files/4 {
+		t.Errorf("got %d files with overlap %+v, want %d with %d overlapping", response.TotalFiles, response.Overlap, files, files/4)
+	}
+	for _, item := range response.Items {
+		if len(item.Paths) != maxPathsToCollect {
+			t.Errorf("%s: collected %d paths, want %d", item.OptionID, len(item.Paths), maxPathsToCollect)
+		}
+	}
+}
+
+func TestAnalyzeActions(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("p1", "cache", "a"), []byte("1234"))
+	memory.WriteFile(testPath("p2", "cache", "a"), []byte("12"))
+	memory.WriteFile(testPath("p2", "cache", "b"), []byte("12"))
+
+	registry := useMemoryRegistry(t)
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("MRU1", "a"))
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("Other", "b"))
+
+	profiles := []models.Profile{{ID: "p1", Name: "First", Path: testPath("p1")}, {ID: "p2", Path: testPath("p2")}}
+	actions := ExpandProfileActions([]models.Action{
+		{Search: "walk.files", Path: detector.ProfilePlaceholder + "/cache"},
+		{Command: models.RegistryDeleteValue, Path: `HKCU\Software\App\Recent`, Value: "mru*"},
+		{Search: "walk.files", Path: testPath("p1"), OS: []string{"no-such-os"}},
+	}, profiles)
+
+	item, err := AnalyzeActions(context.Background(), models.CleanRequest{CleanerID: "app", OptionID: "cache"}, actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Size != 8 || item.FileCount != 3 {
+		t.Errorf("got %d bytes in %d files, want 8 bytes in 3 files", item.Size, item.FileCount)
+	}
+
+	wantProfiles := []models.ProfileBreakdown{
+		{Profile: "p1", Name: "First", Size: 4, FileCount: 1},
+		{Profile: "p2", Size: 4, FileCount: 2},
+	}
+	if len(item.Profiles) != len(wantProfiles) {
+		t.Fatalf("got profiles %v, want %v", item.Profiles, wantProfiles)
+	}
+	for i := range wantProfiles {
+		if item.Profiles[i] != wantProfiles[i] {
+			t.Errorf("profile %d: got %v, want %v", i, item.Profiles[i], wantProfiles[i])
+		}
+	}
+
+	if len(item.Registry) != 1 || item.Registry[0].Value != "MRU1" {
+		t.Errorf("got registry targets %v, want only MRU1", item.Registry)
+	}
+}
+
+func TestLoadCleanerMapFiltersPlatform(t *testing.T) {
+	memory := useMemoryFS(t)
+	action := `{"command": "delete", "search": "file", "path": "x"}`
+	definitions := map[string]string{
+		"here.json": `{"id": "here", "name": "Here", "description": "", "detect": {"type": "always"},
+			"os": ["` + runtime.GOOS + `"], "arch": ["` + runtime.GOARCH + `"],
+			"options": [
+				{"id": "any", "label": "Any", "description": "", "actions": [` + action + `]},
+				{"id": "this-os", "label": "This OS", "description": "", "os": ["` + runtime.GOOS + `"], "actions": [` + action + `]},
+				{"id": "other-os", "label": "Other OS", "description": "", "os": ["no-such-os"], "actions": [` + action + `]},
+				{"id": "other-arch", "label": "Other arch", "description": "", "arch": ["no-such-arch"], "actions": [` + action + `]}
+			]}`,
+		"elsewhere.json": `{"id": "elsewhere", "name": "Elsewhere", "description": "", "detect": {"type": "always"}, "os": ["no-such-os"],
+			"options": [{"id": "any", "label": "Any", "description": "", "actions": [` + action + `]}]}`,
+		"other-arch.json": `{"id": "other-arch", "name": "Other arch", "description": "", "detect": {"type": "always"}, "arch": ["no-such-arch"],
+			"options": [{"id": "any", "label": "Any", "description": "", "actions": [` + action + `]}]}`,
+	}
+	for name, definition := range definitions {
+		memory.WriteFile(filepath.Join("resources", name), []byte(definition))
+	}
+
+	cleanerMap, err := LoadCleanerMap(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cleanerMap) != 1 || cleanerMap["here"] == nil {
+		t.Fatalf("got cleaners %v, want only here", cleanerMap)
+	}
+	var options []string
+	for id := range cleanerMap["here"] {
+		options = append(options, id)
+	}
+	sort.Strings(options)
+	if want := []string{"any", "this-os"}; !slices.Equal(options, want) {
+		t.Errorf("got options %v, want %v", options, want)
+	}
+}
+
+func TestExpandProfileActions(t *testing.T) {
+	profiles := []models.Profile{{ID: "p1", Path: testPath("p1")}, {ID: "p2", Path: testPath("p2")}}
+	actions := ExpandProfileActions([]models.Action{
+		{
+			Search:  "walk.files",
+			Path:    detector.ProfilePlaceholder + "/cache",
+			Include: []string{"*.log"},
+			Exclude: []string{detector.ProfilePlaceholder + "/cache/keep"},
+		},
+		{Search: "file", Path: testPath("shared")},
+	}, profiles)
+
+	if len(actions) != 3 {
+		t.Fatalf("got %d actions, want 3", len(actions))
+	}
+	for i, profile := range profiles {
+		action := actions[i]
+		if action.Profile == nil || action.Profile.ID != profile.ID {
+			t.Errorf("action %d: got profile %v, want %s", i, action.Profile, profile.ID)
+		}
+		if want := profile.Path + "/cache"; action.Path != want {
+			t.Errorf("action %d: got path %q, want %q", i, action.Path, want)
+		}
+		if len(action.Include) != 1 || action.Include[0] != "*.log" {
+			t.Errorf("action %d: got include %v, want the name pattern kept", i, action.Include)
+		}
+		if want := profile.Path + "/cache/keep"; len(action.Exclude) != 1 || action.Exclude[0] != want {
+			t.Errorf("action %d: got exclude %v, want [%s]", i, action.Exclude, want)
+		}
+	}
+	if actions[2].Profile != nil || actions[2].Path != testPath("shared") {
+		t.Errorf("got %+v, want the action without a placeholder kept as is", actions[2])
+	}
+}
+
+func TestAnalyzeActionsCancelled(t *testing.T) {
+	useMemoryFS(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := AnalyzeActions(ctx, models.CleanRequest{}, []models.Action{{Search: "walk.files", Path: root}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
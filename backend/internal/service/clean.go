@@ -0,0 +1,248 @@
+package service
+
+import (
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrRegistryBackup is returned by CleanRequests when the registry backup can't be written
+var ErrRegistryBackup = errors.New("registry backup failed")
+
+// CleanRequests removes what AnalyzeRequests reports for the same requests.
+//
+// Registry entries of every request are exported to a single .reg backup
+// before anything is deleted; if the backup fails, nothing is touched.
+// Requests are cleaned one after another; unknown IDs get an item with an
+// unknown_cleaner or unknown_option error. On cancellation the items cleaned so
+// far are returned together with the context error.
+func CleanRequests(ctx context.Context, requests []models.CleanRequest,
+	cleanerMap map[string]map[string][]models.Action) (*models.CleanResponse, error) {
+	response := &models.CleanResponse{
+		Items: make([]models.CleanItem, 0),
+	}
+
+	type plannedRequest struct {
+		request  models.CleanRequest
+		actions  []models.Action
+		registry [][]models.RegistryEntry // targets per action, nil for file actions
+		unknown  *models.ItemError        // set when the IDs don't match any option
+	}
+
+	var planned []plannedRequest
+	var registryEntries []models.RegistryEntry
+	freed := NewFileSet()
+
+	for _, request := range requests {
+		if itemError, unknown := unknownIDError(request, cleanerMap); unknown {
+			planned = append(planned, plannedRequest{request: request, unknown: &itemError})
+			continue
+		}
+		actions := cleanerMap[request.CleanerID][request.OptionID]
+
+		plan := plannedRequest{request: request, actions: actions, registry: make([][]models.RegistryEntry, len(actions))}
+		for i, action := range actions {
+			if IsRegistryCommand(action.Command) && detector.IsOSSupported(action.OS) {
+				plan.registry[i] = RegistryTargets(action)
+				registryEntries = append(registryEntries, plan.registry[i]...)
+			}
+		}
+		planned = append(planned, plan)
+	}
+
+	if len(registryEntries) > 0 {
+		backupPath, err := BackupRegistry(registryEntries)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRegistryBackup, err)
+		}
+		response.RegistryBackup = backupPath
+		slog.Info("Registry backed up", "file", backupPath, "entries", len(registryEntries))
+	}
+
+	for _, plan := range planned {
+		if ctx.Err() != nil {
+			return response, ctx.Err()
+		}
+
+		if plan.unknown != nil {
+			response.Items = append(response.Items, models.CleanItem{
+				CleanerID: plan.request.CleanerID,
+				OptionID:  plan.request.OptionID,
+				Errors:    []models.ItemError{*plan.unknown},
+			})
+			continue
+		}
+
+		item := CleanActions(ctx, plan.request, plan.actions, plan.registry, freed)
+
+		response.Items = append(response.Items, item)
+		response.TotalSize += item.Size
+		response.TotalFiles += item.FileCount
+		response.TotalDirs += item.DirCount
+		response.TotalFailed += item.Failed
+		response.TotalShredded += item.Shredded
+		response.TotalShredIneffective += item.ShredIneffective
+	}
+
+	return response, ctx.Err()
+}
+
+// CleanActions runs the actions of a single cleaner option. Files are found
+// exactly as during preview; registry actions delete the targets planned for
+// them (registry[i] belongs to actions[i]).
+//
+// Walks that delete empty folders remove them once their files are gone, as
+// do folders matched as a whole. "shred" overwrites files before deleting
+// them; those the file system likely kept the old data of are reported as
+// shred_ineffective errors. Nothing is cleaned while one of the processes the
+// actions carry is running; the item then holds a single app_running error.
+//
+// The bytes of a removed file count only if it isn't in freed yet, so removing
+// several hard links of a file frees its size once. freed may be nil.
+func CleanActions(ctx context.Context, request models.CleanRequest, actions []models.Action,
+	registry [][]models.RegistryEntry, freed *FileSet) models.CleanItem {
+	item := models.CleanItem{
+		CleanerID: request.CleanerID,
+		OptionID:  request.OptionID,
+	}
+	var mutex sync.Mutex
+	var errs errorCollector
+
+	if itemError, running := appRunningError(actions); running {
+		slog.Warn("Option skipped", "cleaner", request.CleanerID, "option", request.OptionID, "reason", itemError.Message)
+		item.Errors = []models.ItemError{itemError}
+		return item
+	}
+
+	for i, action := range actions {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if !detector.IsOSSupported(action.OS) {
+			continue
+		}
+
+		if IsRegistryCommand(action.Command) {
+			for _, entry := range registry[i] {
+				if err := DeleteRegistryEntry(entry); err != nil {
+					slog.Warn("Error deleting registry entry", "key", entry.Key, "value", entry.Value, "error", err)
+					item.Failed++
+					continue
+				}
+				item.Registry = append(item.Registry, entry)
+			}
+			continue
+		}
+
+		if action.Command == "vacuum" {
+			slog.Warn("Vacuum is not supported, action skipped", "cleaner", request.CleanerID,
+				"option", request.OptionID, "path", action.Path)
+			continue
+		}
+
+		VisitActionFiles(ctx, action, Visitor{
+			File: func(path string, info fs.FileInfo) {
+				id := filesystem.Identify(path, info)
+				var overwritten bool
+				var ineffective string
+				var err error
+				if action.Command == "shred" {
+					overwritten, ineffective, err = shredFile(action, path, info)
+				} else {
+					err = cleanFile(action.Command, path)
+				}
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if err != nil {
+					slog.Warn("Error cleaning file", "path", path, "error", err)
+					errs.addKind(classifyDeleteError(err, info), path, err)
+					item.Failed++
+					return
+				}
+				if ineffective != "" {
+					errs.addKind(ErrorShredIneffective, path, errors.New(ineffective))
+					item.ShredIneffective++
+				} else if overwritten {
+					item.Shredded++
+				}
+				if freed.AddID(id, uint64(info.Size()), filesystem.AllocatedSize(path, info)) {
+					item.Size += uint64(info.Size())
+				}
+				item.FileCount++
+			},
+			Error: errs.add,
+		})
+
+		VisitEmptyDirs(ctx, action, nil, func(dir string) bool {
+			info, err := filesystem.Current().Lstat(dir)
+			if err == nil {
+				err = filesystem.Current().Remove(dir)
+			}
+			if err != nil {
+				slog.Warn("Error removing empty folder", "path", dir, "error", err)
+				errs.addKind(classifyDeleteError(err, info), dir, err)
+				return false
+			}
+			item.DirCount++
+			return true
+		})
+	}
+
+	item.Errors = errs.list()
+	item.ErrorSummary = errs.summarize()
+	return item
+}
+
+// appRunningError reports whether one of the processes the actions name is
+// running. When the running programs can't be listed the option is refused
+// too, as cleaning an app's files while it runs can corrupt them.
+func appRunningError(actions []models.Action) (models.ItemError, bool) {
+	var processes []string
+	for _, action := range actions {
+		for _, process := range action.Processes {
+			if !slices.Contains(processes, process) {
+				processes = append(processes, process)
+			}
+		}
+	}
+
+	name, running, err := detector.FindRunning(processes)
+	if err != nil {
+		return models.ItemError{
+			Kind:    ErrorAppRunning,
+			Message: fmt.Sprintf("can't check whether %s is running: %v", strings.Join(processes, ", "), err),
+		}, true
+	}
+	if running {
+		return models.ItemError{
+			Kind:    ErrorAppRunning,
+			Message: fmt.Sprintf("%s is running; close it before cleaning", name),
+		}, true
+	}
+	return models.ItemError{}, false
+}
+
+// cleanFile applies a file command: "delete" removes the file and "truncate"
+// empties it. Other commands are refused rather than treated as a delete.
+func cleanFile(command string, path string) error {
+	fsys := filesystem.Current()
+	switch command {
+	case "delete":
+		return fsys.Remove(path)
+	case "truncate":
+		return fsys.Truncate(path, 0)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
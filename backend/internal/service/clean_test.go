@@ -0,0 +1,626 @@
+package service
+
+import (
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCleanRequests(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("cache", "a.tmp"), []byte("1234"))
+	memory.WriteFile(testPath("cache", "sub", "b.log"), []byte("12"))
+	memory.WriteFile(testPath("logs", "app.log"), []byte("123456"))
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {
+		"cache": {{Command: "delete", Search: "walk.files", Path: testPath("cache"), Include: []string{"*.tmp"}}},
+		"logs":  {{Command: "truncate", Search: "file", Path: testPath("logs", "app.log")}},
+	}}
+	requests := []models.CleanRequest{{CleanerID: "app", OptionID: "cache"}, {CleanerID: "app", OptionID: "logs"}, {CleanerID: "app", OptionID: "missing"}}
+
+	response, err := CleanRequests(context.Background(), requests, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Items) != 3 || response.TotalSize != 10 || response.TotalFiles != 2 || response.TotalFailed != 0 {
+		t.Errorf("got %+v", *response)
+	}
+	if errs := response.Items[2].Errors; len(errs) != 1 || errs[0].Kind != ErrorUnknownOption {
+		t.Errorf("missing option reported as %+v, want one %s error", errs, ErrorUnknownOption)
+	}
+	if filesystem.Exists(memory, testPath("cache", "a.tmp")) {
+		t.Error("a.tmp not deleted")
+	}
+	if !filesystem.Exists(memory, testPath("cache", "sub", "b.log")) {
+		t.Error("b.log deleted although not included")
+	}
+	if info, err := memory.Stat(testPath("logs", "app.log")); err != nil || info.Size() != 0 {
+		t.Errorf("app.log not truncated: %v, %v", info, err)
+	}
+}
+
+func TestCleanActionsUnknownCommand(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("cache", "a.tmp"), []byte("1234"))
+
+	for _, command := range []string{"", "delte", "sqlite.vacuum"} {
+		actions := []models.Action{{Command: command, Search: "walk.files", Path: testPath("cache")}}
+		item := CleanActions(context.Background(), models.CleanRequest{CleanerID: "app", OptionID: "cache"}, actions, nil, nil)
+
+		if item.Failed != 1 || item.FileCount != 0 {
+			t.Errorf("command %q: got %d failed and %d cleaned, want the file refused", command, item.Failed, item.FileCount)
+		}
+	}
+	if !filesystem.Exists(memory, testPath("cache", "a.tmp")) {
+		t.Error("file deleted by an unknown command")
+	}
+}
+
+// failingProcesses can't list the running programs
+type failingProcesses struct{}
+
+func (failingProcesses) RunningProcesses() ([]string, error) {
+	return nil, errors.New("no process list")
+}
+
+func TestCleanActionsSkipsRunningApp(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("cache", "a.tmp"), []byte("1234"))
+	actions := []models.Action{{Command: "delete", Search: "walk.files", Path: testPath("cache"), Processes: []string{"app"}}}
+	request := models.CleanRequest{CleanerID: "app", OptionID: "cache"}
+
+	for name, lister := range map[string]detector.ProcessLister{
+		"running":          detector.ProcessList{"init", "App.exe"},
+		"can't be checked": failingProcesses{},
+	} {
+		useProcesses(t, lister)
+		item := CleanActions(context.Background(), request, actions, nil, nil)
+
+		if len(item.Errors) != 1 || item.Errors[0].Kind != ErrorAppRunning || item.FileCount != 0 {
+			t.Errorf("%s: got %+v, want a single %s error", name, item, ErrorAppRunning)
+		}
+		if !filesystem.Exists(memory, testPath("cache", "a.tmp")) {
+			t.Fatalf("%s: file deleted while the app is running", name)
+		}
+	}
+
+	useProcesses(t, detector.ProcessList{"init", "other"})
+	if item := CleanActions(context.Background(), request, actions, nil, nil); item.FileCount != 1 || len(item.Errors) != 0 {
+		t.Errorf("got %+v, want the file cleaned once the app is closed", item)
+	}
+}
+
+func TestCleanRequestsRegistry(t *testing.T) {
+	memory := useMemoryFS(t)
+	registry := useMemoryRegistry(t)
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("MRU1", "a"))
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("Other", "b"))
+	registry.SetValue(`HKCU\Software\App\Cache\Sub`, detector.DWordValue("Size", 1))
+
+	backupDir := testPath("backups")
+	useBackupDir(t, backupDir)
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {"registry": {
+		{Command: models.RegistryDeleteValue, Path: `HKCU\Software\App\Recent`, Value: "MRU*"},
+		{Command: models.RegistryDeleteKey, Path: `HKCU\Software\App\Cache`},
+	}}}
+
+	response, err := CleanRequests(context.Background(), []models.CleanRequest{{CleanerID: "app", OptionID: "registry"}}, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Items) != 1 || len(response.Items[0].Registry) != 2 {
+		t.Fatalf("got %+v", *response)
+	}
+	if _, ok := registry.ReadValue(`HKCU\Software\App\Recent`, "MRU1"); ok {
+		t.Error("MRU1 not deleted")
+	}
+	if _, ok := registry.ReadValue(`HKCU\Software\App\Recent`, "Other"); !ok {
+		t.Error("Other deleted although it doesn't match")
+	}
+	if registry.KeyExists(`HKCU\Software\App\Cache\Sub`) {
+		t.Error("Cache key not deleted with its subkeys")
+	}
+
+	if filepath.Dir(response.RegistryBackup) != backupDir {
+		t.Fatalf("backup written to %q, want it in %q", response.RegistryBackup, backupDir)
+	}
+	if info, err := memory.Stat(response.RegistryBackup); err != nil || info.Size() == 0 {
+		t.Errorf("backup missing or empty: %v", err)
+	}
+}
+
+func TestCleanRequestsBackupFailureKeepsEverything(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("cache", "a.tmp"), []byte("1234"))
+	registry := useMemoryRegistry(t)
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("MRU1", "a"))
+
+	// a file where the backup directory should be makes MkdirAll fail
+	memory.WriteFile(testPath("blocker"), nil)
+	useBackupDir(t, testPath("blocker", "backups"))
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {"all": {
+		{Command: "delete", Search: "walk.files", Path: testPath("cache")},
+		{Command: models.RegistryDeleteValue, Path: `HKCU\Software\App\Recent`, Value: "*"},
+	}}}
+
+	_, err := CleanRequests(context.Background(), []models.CleanRequest{{CleanerID: "app", OptionID: "all"}}, cleanerMap)
+	if !errors.Is(err, ErrRegistryBackup) {
+		t.Fatalf("got %v, want ErrRegistryBackup", err)
+	}
+	if !filesystem.Exists(memory, testPath("cache", "a.tmp")) {
+		t.Error("file deleted although the backup failed")
+	}
+	if _, ok := registry.ReadValue(`HKCU\Software\App\Recent`, "MRU1"); !ok {
+		t.Error("registry value deleted although the backup failed")
+	}
+}
+
+func TestCleanRequestsCancelled(t *testing.T) {
+	memory := useMemoryFS(t)
+	memory.WriteFile(testPath("cache", "a.tmp"), []byte("1234"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {"cache": {{Command: "delete", Search: "walk.files", Path: testPath("cache")}}}}
+	response, err := CleanRequests(ctx, []models.CleanRequest{{CleanerID: "app", OptionID: "cache"}}, cleanerMap)
+
+	if !errors.Is(err, context.Canceled) || response == nil || len(response.Items) != 0 {
+		t.Errorf("got %v, %v; want an empty partial response and context.Canceled", response, err)
+	}
+	if !filesystem.Exists(memory, testPath("cache", "a.tmp")) {
+		t.Error("file deleted after cancellation")
+	}
+}
+
+// lockedFS fails to remove the files listed in locked with their error
+type lockedFS struct {
+	*filesystem.Memory
+	locked map[string]error
+}
+
+func (l lockedFS) Remove(name string) error {
+	if err, ok := l.locked[name]; ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return l.Memory.Remove(name)
+}
+
+func TestCleanRequestsSummarizesFailures(t *testing.T) {
+	memory := filesystem.NewMemory()
+	for _, name := range []string{"a.tmp", "b.tmp", "c.tmp", "d.tmp"} {
+		memory.WriteFile(testPath("cache", name), []byte("12"))
+	}
+	useFS(t, lockedFS{Memory: memory, locked: map[string]error{
+		testPath("cache", "a.tmp"): fs.ErrPermission,
+		testPath("cache", "b.tmp"): fs.ErrPermission,
+		testPath("cache", "c.tmp"): errors.New("unexpected"),
+	}})
+
+	cleanerMap := map[string]map[string][]models.Action{"app": {"cache": {{Command: "delete", Search: "walk.files", Path: testPath("cache")}}}}
+	response, err := CleanRequests(context.Background(), []models.CleanRequest{{CleanerID: "app", OptionID: "cache"}}, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := response.Items[0]
+	if item.FileCount != 1 || item.Failed != 3 {
+		t.Fatalf("got %d deleted and %d failed, want 1 and 3", item.FileCount, item.Failed)
+	}
+
+	counts := make(map[string]uint64)
+	for _, group := range item.ErrorSummary {
+		counts[group.Kind] = group.Count
+		if uint64(len(group.Samples)) != group.Count {
+			t.Errorf("%s: %d samples for %d errors", group.Kind, len(group.Samples), group.Count)
+		}
+	}
+	if counts[ErrorPermissionDenied] != 2 || counts[ErrorDeleteFailed] != 1 || len(counts) != 2 {
+		t.Errorf("got summary %+v", item.ErrorSummary)
+	}
+}
+
+func TestCleanRemovesEmptyDirs(t *testing.T) {
+	build := func(t *testing.T) *filesystem.Memory {
+		memory := useMemoryFS(t)
+		memory.WriteFile(testPath("shaders", "v1", "a", "1.bin"), []byte("1"))
+		memory.WriteFile(testPath("shaders", "v1", "b", "2.bin"), []byte("2"))
+		memory.WriteFile(testPath("shaders", "v2", "3.bin"), []byte("3"))
+		memory.WriteFile(testPath("shaders", "v2", "keep.txt"), []byte("4"))
+		memory.WriteFile(testPath("shaders", "kept", "4.bin"), []byte("5"))
+		memory.MkdirAll(testPath("shaders", "empty"))
+		return memory
+	}
+
+	tests := []struct {
+		name     string
+		action   models.Action
+		wantDirs uint64
+		gone     []string
+		kept     []string
+	}{
+		{
+			"walk.all keeps the root",
+			models.Action{Search: "walk.all", Path: testPath("shaders"), Include: []string{"*.bin"}, Exclude: []string{testPath("shaders", "kept")}},
+			4,
+			[]string{"v1", "empty"},
+			[]string{"", "v2", "kept"},
+		},
+		{
+			"remove root",
+			models.Action{Search: "walk.files", DeleteEmptyDirs: true, RemoveRoot: true, Path: testPath("shaders")},
+			7,
+			[]string{"", "v1", "v2", "kept", "empty"},
+			nil,
+		},
+		{
+			"walk.files keeps folders",
+			models.Action{Search: "walk.files", Path: testPath("shaders"), Include: []string{"*.bin"}},
+			0,
+			nil,
+			[]string{"", "v1", "empty"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memory := build(t)
+			action := test.action
+			action.Command = "delete"
+			cleanerMap := map[string]map[string][]models.Action{"app": {"shaders": {action}}}
+			requests := []models.CleanRequest{{CleanerID: "app", OptionID: "shaders"}}
+
+			preview, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := CleanRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if preview.TotalDirs != test.wantDirs || response.TotalDirs != test.wantDirs {
+				t.Errorf("preview counted %d folders, clean removed %d, want %d", preview.TotalDirs, response.TotalDirs, test.wantDirs)
+			}
+			for _, dir := range test.gone {
+				if filesystem.Exists(memory, testPath("shaders", dir)) {
+					t.Errorf("%q not removed", dir)
+				}
+			}
+			for _, dir := range test.kept {
+				if !filesystem.Exists(memory, testPath("shaders", dir)) {
+					t.Errorf("%q removed", dir)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanDirSearches(t *testing.T) {
+	build := func(t *testing.T) *filesystem.Memory {
+		memory := useMemoryFS(t)
+		memory.WriteFile(testPath("app", "1.0", "a.bin"), []byte("1"))
+		memory.WriteFile(testPath("app", "1.0", "sub", "b.bin"), []byte("12"))
+		memory.WriteFile(testPath("app", "2.0", "c.bin"), []byte("123"))
+		memory.WriteFile(testPath("app", "current", "keep"), []byte("1234"))
+		memory.WriteFile(testPath("app", "root.txt"), []byte("12345"))
+		memory.WriteFile(testPath("app", "deep", "3.0", "d.bin"), []byte("123456"))
+		return memory
+	}
+
+	tests := []struct {
+		name     string
+		action   models.Action
+		wantSize uint64
+		wantDirs uint64
+		gone     []string
+		kept     []string
+	}{
+		{
+			"walk.dirs",
+			models.Action{Search: "walk.dirs", Path: testPath("app"), Include: []string{"*.*"}},
+			12, 4,
+			[]string{"1.0", "2.0", filepath.Join("deep", "3.0")},
+			[]string{"", "deep", "current", "root.txt"},
+		},
+		{
+			"walk.dirs with depth",
+			models.Action{Search: "walk.dirs", Path: testPath("app"), Include: []string{"*.*"}, Depth: 1},
+			6, 3,
+			[]string{"1.0", "2.0"},
+			[]string{"", filepath.Join("deep", "3.0")},
+		},
+		{
+			"walk.top",
+			models.Action{Search: "walk.top", Path: testPath("app"), Exclude: []string{testPath("app", "current")}},
+			17, 5,
+			[]string{"1.0", "2.0", "deep", "root.txt"},
+			[]string{"", filepath.Join("current", "keep")},
+		},
+		{
+			"walk.files with depth",
+			models.Action{Search: "walk.files", Path: testPath("app"), Depth: 1},
+			5, 0,
+			[]string{"root.txt"},
+			[]string{filepath.Join("1.0", "a.bin")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memory := build(t)
+			action := test.action
+			action.Command = "delete"
+			cleanerMap := map[string]map[string][]models.Action{"app": {"versions": {action}}}
+			requests := []models.CleanRequest{{CleanerID: "app", OptionID: "versions"}}
+
+			preview, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := CleanRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if preview.TotalApparentSize != test.wantSize || preview.TotalDirs != test.wantDirs {
+				t.Errorf("preview: got %d bytes and %d folders, want %d and %d", preview.TotalApparentSize, preview.TotalDirs, test.wantSize, test.wantDirs)
+			}
+			if response.TotalSize != test.wantSize || response.TotalDirs != test.wantDirs {
+				t.Errorf("clean: got %d bytes and %d folders, want %d and %d", response.TotalSize, response.TotalDirs, test.wantSize, test.wantDirs)
+			}
+			for _, name := range test.gone {
+				if filesystem.Exists(memory, testPath("app", name)) {
+					t.Errorf("%q not removed", name)
+				}
+			}
+			for _, name := range test.kept {
+				if !filesystem.Exists(memory, testPath("app", name)) {
+					t.Errorf("%q removed", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanNameFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   models.Action
+		wantSize uint64
+		gone     []string
+	}{
+		{
+			"include",
+			models.Action{Include: []string{"*.log", "*.dmp"}},
+			3, []string{"a.log", filepath.Join("sub", "b.dmp")},
+		},
+		{
+			"regex",
+			models.Action{Regex: `^f_[0-9a-f]{6}$`},
+			12, []string{"f_00beef", filepath.Join("sub", "f_c0ffee")},
+		},
+		{
+			"include and regex",
+			models.Action{Include: []string{"*.log"}, Regex: `^a`},
+			1, []string{"a.log"},
+		},
+		{
+			"invalid regex",
+			models.Action{Regex: `(`},
+			0, nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memory := useMemoryFS(t)
+			files := map[string]string{
+				"a.log":                          "1",
+				filepath.Join("sub", "b.dmp"):    "12",
+				"c.txt":                          "123",
+				"f_00beef":                       "1234",
+				filepath.Join("sub", "f_c0ffee"): "12345678",
+				"f_00beef.bak":                   "12345",
+			}
+			for name, data := range files {
+				memory.WriteFile(testPath("app", name), []byte(data))
+			}
+
+			action := test.action
+			action.Command, action.Search, action.Path = "delete", "walk.files", testPath("app")
+			cleanerMap := map[string]map[string][]models.Action{"app": {"logs": {action}}}
+			requests := []models.CleanRequest{{CleanerID: "app", OptionID: "logs"}}
+
+			preview, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := CleanRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if preview.TotalApparentSize != test.wantSize || response.TotalSize != test.wantSize {
+				t.Errorf("got %d bytes previewed and %d cleaned, want %d", preview.TotalApparentSize, response.TotalSize, test.wantSize)
+			}
+			if response.TotalFiles != uint64(len(test.gone)) {
+				t.Errorf("cleaned %d files, want %d", response.TotalFiles, len(test.gone))
+			}
+			for _, name := range test.gone {
+				if filesystem.Exists(memory, testPath("app", name)) {
+					t.Errorf("%q not removed", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanSizeAndRetention(t *testing.T) {
+	// log files of n bytes, n days old: the newest is the smallest
+	build := func(t *testing.T) *filesystem.Memory {
+		memory := useMemoryFS(t)
+		now := time.Now()
+		for n := 1; n <= 5; n++ {
+			name := testPath("logs", fmt.Sprintf("day%d", n), fmt.Sprintf("app%d.log", n))
+			memory.WriteFile(name, []byte(strings.Repeat("x", n)))
+			if err := memory.Chtimes(name, now.Add(-time.Duration(n)*24*time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return memory
+	}
+
+	tests := []struct {
+		name     string
+		action   models.Action
+		wantDays []int // days whose log is targeted
+		wantDirs uint64
+	}{
+		{"min_size", models.Action{MinSize: 4}, []int{4, 5}, 0},
+		{"max_size", models.Action{MaxSize: 2}, []int{1, 2}, 0},
+		{"size range", models.Action{MinSize: 2, MaxSize: 3}, []int{2, 3}, 0},
+		{"keep_newest", models.Action{KeepNewest: 2}, []int{3, 4, 5}, 0},
+		{"keep_total_size", models.Action{KeepTotalSize: 6}, []int{4, 5}, 0},
+		{"keep_newest and keep_total_size", models.Action{KeepNewest: 3, KeepTotalSize: 3}, []int{3, 4, 5}, 0},
+		{"keep_newest after min_size", models.Action{MinSize: 2, KeepNewest: 1}, []int{3, 4, 5}, 0},
+		{"keep_newest of everything", models.Action{KeepNewest: 10}, nil, 0},
+		{"walk.all keeps folders of kept files", models.Action{Search: "walk.all", KeepNewest: 3}, []int{4, 5}, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			memory := build(t)
+			action := test.action
+			action.Command, action.Path = "delete", testPath("logs")
+			if action.Search == "" {
+				action.Search = "walk.files"
+			}
+			cleanerMap := map[string]map[string][]models.Action{"app": {"logs": {action}}}
+			requests := []models.CleanRequest{{CleanerID: "app", OptionID: "logs"}}
+
+			var wantSize uint64
+			for _, day := range test.wantDays {
+				wantSize += uint64(day)
+			}
+
+			preview, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := CleanRequests(context.Background(), requests, cleanerMap)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if preview.TotalApparentSize != wantSize || preview.TotalDirs != test.wantDirs {
+				t.Errorf("preview: got %d bytes and %d folders, want %d and %d", preview.TotalApparentSize, preview.TotalDirs, wantSize, test.wantDirs)
+			}
+			if response.TotalSize != wantSize || response.TotalDirs != test.wantDirs {
+				t.Errorf("clean: got %d bytes and %d folders, want %d and %d", response.TotalSize, response.TotalDirs, wantSize, test.wantDirs)
+			}
+			for n := 1; n <= 5; n++ {
+				name := testPath("logs", fmt.Sprintf("day%d", n), fmt.Sprintf("app%d.log", n))
+				if filesystem.Exists(memory, name) == slices.Contains(test.wantDays, n) {
+					t.Errorf("day %d: removed %v, want %v", n, !filesystem.Exists(memory, name), slices.Contains(test.wantDays, n))
+				}
+			}
+		})
+	}
+}
+
+// overwriteFS records what every overwrite left in a file, so the data of
+// files deleted afterwards can still be checked
+type overwriteFS struct {
+	*filesystem.Memory
+	mutex       *sync.Mutex
+	overwritten map[string]string
+}
+
+func (o overwriteFS) Overwrite(name string, fill func(block []byte)) error {
+	if err := o.Memory.Overwrite(name, fill); err != nil {
+		return err
+	}
+	data, err := o.Memory.ReadFile(name)
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.overwritten[name] = string(data)
+	return err
+}
+
+func TestCleanShred(t *testing.T) {
+	memory := useMemoryFS(t)
+	recorder := overwriteFS{Memory: memory, mutex: &sync.Mutex{}, overwritten: make(map[string]string)}
+	useFS(t, recorder)
+	memory.WriteFile(testPath("session", "cookies"), []byte("secret"))
+	memory.WriteFile(testPath("session", "history"), []byte("history"))
+	memory.WriteFile(testPath("session", "shared"), []byte("shared"))
+	memory.WriteFile(testPath("kept", "target"), []byte("target"))
+	memory.Symlink(testPath("kept", "target"), testPath("session", "link"))
+	// a hard link outside the action keeps "shared" from being overwritten
+	if err := memory.Link(testPath("session", "shared"), testPath("kept", "shared")); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.SetOverwriteIneffective(testPath("session", "history"), "copy-on-write file system (test)"); err != nil {
+		t.Fatal(err)
+	}
+
+	action := models.Action{Command: "shred", Search: "walk.files", Path: testPath("session"), Passes: 3, Fill: models.ShredFillZero}
+	cleanerMap := map[string]map[string][]models.Action{"browser": {"session": {action}}}
+	requests := []models.CleanRequest{{CleanerID: "browser", OptionID: "session"}}
+
+	preview, err := AnalyzeRequests(context.Background(), requests, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := preview.Items[0]; item.ShredBytes != 3*(6+7) || item.ShredIneffective != 1 || preview.TotalShredBytes != item.ShredBytes {
+		t.Errorf("preview: %d bytes to write, %d ineffective, %d in total", item.ShredBytes, item.ShredIneffective, preview.TotalShredBytes)
+	}
+
+	response, err := CleanRequests(context.Background(), requests, cleanerMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := response.Items[0]
+	if item.FileCount != 4 || item.Shredded != 1 || item.ShredIneffective != 1 || item.Failed != 0 {
+		t.Errorf("clean: %d files, %d shredded, %d ineffective, %d failed", item.FileCount, item.Shredded, item.ShredIneffective, item.Failed)
+	}
+	if response.TotalShredded != 1 || response.TotalShredIneffective != 1 {
+		t.Errorf("totals: %d shredded, %d ineffective", response.TotalShredded, response.TotalShredIneffective)
+	}
+	if len(item.ErrorSummary) != 1 || item.ErrorSummary[0].Kind != ErrorShredIneffective {
+		t.Errorf("error summary: %+v", item.ErrorSummary)
+	}
+
+	for _, name := range []string{"cookies", "history", "shared", "link"} {
+		if filesystem.Exists(memory, testPath("session", name)) {
+			t.Errorf("%q not removed", name)
+		}
+	}
+	if data := recorder.overwritten[testPath("session", "cookies")]; data != "\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("cookies not overwritten: %q", data)
+	}
+	if data, ok := recorder.overwritten[testPath("session", "shared")]; ok {
+		t.Errorf("hard-linked file overwritten: %q", data)
+	}
+	if data, _ := memory.ReadFile(testPath("kept", "shared")); string(data) != "shared" {
+		t.Errorf("other hard link changed: %q", data)
+	}
+	if data, _ := memory.ReadFile(testPath("kept", "target")); string(data) != "target" {
+		t.Errorf("link target overwritten: %q", data)
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"io/fs"
+	"sync"
+)
+
+// FileSet remembers the files counted so far, by identity rather than path,
+// so hard links and files matched by overlapping actions are counted once.
+// A nil FileSet counts everything.
+type FileSet struct {
+	mutex  sync.Mutex
+	files  map[filesystem.FileID]struct{}
+	repeat models.FileTotals // files added again and their bytes
+}
+
+// NewFileSet returns an empty set
+func NewFileSet() *FileSet {
+	return &FileSet{files: make(map[filesystem.FileID]struct{})}
+}
+
+// Add records the file and reports whether it wasn't in the set yet
+func (set *FileSet) Add(path string, info fs.FileInfo) bool {
+	if set == nil {
+		return true
+	}
+	return set.AddID(filesystem.Identify(path, info), uint64(info.Size()), filesystem.AllocatedSize(path, info))
+}
+
+// AddID is Add for a file identified beforehand, e.g. because it is deleted
+// before being added
+func (set *FileSet) AddID(id filesystem.FileID, size uint64, allocated uint64) bool {
+	if set == nil {
+		return true
+	}
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	if _, ok := set.files[id]; ok {
+		set.repeat.Size += size
+		set.repeat.AllocatedSize += allocated
+		set.repeat.FileCount++
+		return false
+	}
+	set.files[id] = struct{}{}
+	return true
+}
+
+// Repeated returns the totals of files that were added more than once, nil if none were
+func (set *FileSet) Repeated() *models.FileTotals {
+	if set == nil {
+		return nil
+	}
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	if set.repeat.FileCount == 0 {
+		return nil
+	}
+	repeat := set.repeat
+	return &repeat
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+)
+
+// IsWalkSearch reports whether the action walks folders recursively for files
+func IsWalkSearch(search string) bool {
+	return search == "walk.files" || search == "walk.all"
+}
+
+// IsDirSearch reports whether the action matches folders and removes them whole
+func IsDirSearch(search string) bool {
+	return search == "walk.dirs" || search == "walk.top"
+}
+
+// prunesEmptyDirs reports whether cleaning the action also removes the folders it leaves empty
+func prunesEmptyDirs(action models.Action) bool {
+	return action.Search == "walk.all" || (action.Search == "walk.files" && action.DeleteEmptyDirs)
+}
+
+// removesDirs reports whether the action removes any folders, see VisitEmptyDirs
+func removesDirs(action models.Action) bool {
+	return IsDirSearch(action.Search) || prunesEmptyDirs(action)
+}
+
+// ProcessDirsAction handles "walk.dirs" and "walk.top".
+//
+// "walk.dirs" matches the folders below each root, down to the filter's depth,
+// whose names the filter allows; "walk.top" matches the root's immediate
+// children, folders and files alike. Every file inside a matched folder is
+// visited, only exclusions apply there. The roots themselves never match.
+func ProcessDirsAction(ctx context.Context, search string, searchPath string, filter PathFilter, visit Visitor) {
+	for _, root := range walkRoots(searchPath, visit) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if search == "walk.top" {
+			ProcessWalkAction(ctx, root, filter.withDepth(1), visit)
+		}
+		for _, dir := range MatchDirs(ctx, search, root, filter, visit) {
+			ProcessWalkAction(ctx, dir, filter.contents(), visit)
+		}
+	}
+}
+
+// MatchDirs returns the folders below root a "walk.dirs" or "walk.top" search
+// matches as a whole. Matched folders aren't searched further.
+func MatchDirs(ctx context.Context, search string, root string, filter PathFilter, visit Visitor) []string {
+	if search == "walk.top" {
+		filter = filter.withDepth(1)
+	}
+
+	var dirs []string
+	err := filesystem.WalkDir(filesystem.Current(), root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			visit.fail(path, err)
+			return nil
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+
+		if filter.Excludes(path) {
+			return fs.SkipDir
+		}
+		if filter.AllowsDir(path) {
+			dirs = append(dirs, path)
+			return fs.SkipDir
+		}
+		if !filter.Descends(walkDepth(root, path)) {
+			return fs.SkipDir
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(ctx.Err(), err) {
+		slog.Error("Error walking directory", "path", root, "error", err)
+	}
+	return dirs
+}
+
+// walkRoots returns the folders a walk starts from: the path itself, or the
+// folders matching it when it has wildcards
+func walkRoots(searchPath string, visit Visitor) []string {
+	fsys := filesystem.Current()
+
+	roots := []string{searchPath}
+	if filesystem.HasMeta(searchPath) {
+		var err error
+		if roots, err = filesystem.GlobAll(fsys, searchPath); err != nil {
+			slog.Error("Error in glob", "path", searchPath, "error", err)
+			visit.fail(searchPath, err)
+			return nil
+		}
+	}
+
+	dirs := roots[:0:0]
+	for _, root := range roots {
+		if info, err := fsys.Stat(root); err == nil && info.IsDir() {
+			dirs = append(dirs, root)
+		}
+	}
+	return dirs
+}
+
+// VisitEmptyDirs goes through the folders the action removes once their
+// files are gone, bottom-up, and calls visit for each that is empty by then;
+// visit returns whether the folder is gone now.
+//
+// Walks that delete empty folders visit those below their roots, and the
+// roots themselves with RemoveRoot. Folder searches visit the matched folders
+// and everything in them. Files for which removed returns true count as
+// gone already, so a preview can pass the files it matched and count the
+// folders; with a nil removed the folders are taken as they are.
+//
+// Excluded folders and their parents are kept, and so are folders beyond the
+// action's depth. Folder links are never followed.
+func VisitEmptyDirs(ctx context.Context, action models.Action, removed func(path string) bool, visit func(dir string) bool) {
+	if !removesDirs(action) {
+		return
+	}
+	dirSearch := IsDirSearch(action.Search)
+
+	fsys := filesystem.Current()
+	filter := NewPathFilter(action)
+	contents := filter.contents()
+
+	for _, root := range walkRoots(detector.ExpandPath(action.Path), Visitor{}) {
+		if ctx.Err() != nil {
+			return
+		}
+		if filter.Excludes(root) {
+			continue
+		}
+
+		if !dirSearch {
+			if visitEmptyDir(ctx, fsys, root, 0, filter, removed, visit) && action.RemoveRoot {
+				visit(root)
+			}
+			continue
+		}
+
+		for _, dir := range MatchDirs(ctx, action.Search, root, filter, Visitor{}) {
+			if visitEmptyDir(ctx, fsys, dir, 0, contents, removed, visit) {
+				visit(dir)
+			}
+		}
+	}
+}
+
+// visitEmptyDir visits the empty folders below dir, which is depth levels
+// below the walk root, and reports whether dir itself would be empty
+func visitEmptyDir(ctx context.Context, fsys filesystem.FS, dir string, depth int, filter PathFilter,
+	gone func(path string) bool, visit func(dir string) bool) bool {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	empty := true
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if filter.Excludes(path) || !filter.Descends(depth+1) ||
+				!visitEmptyDir(ctx, fsys, path, depth+1, filter, gone, visit) || !visit(path) {
+				empty = false
+			}
+			continue
+		}
+
+		if gone == nil || !gone(path) {
+			empty = false
+		}
+	}
+	return empty
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"backend/internal/models"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync"
+)
+
+// Kinds of models.ItemError
+const (
+	ErrorUnknownCleaner   = "unknown_cleaner"
+	ErrorUnknownOption    = "unknown_option"
+	ErrorPermissionDenied = "permission_denied"
+	ErrorGlobSyntax       = "glob_syntax"
+	ErrorWalkFailed       = "walk_failed"
+	ErrorInUse            = "in_use"
+	ErrorReadOnly         = "read_only"
+	ErrorDeleteFailed     = "delete_failed"
+	// ErrorShredIneffective marks a shredded file that was deleted, but whose
+	// data the file system likely kept, e.g. on a copy-on-write file system
+	ErrorShredIneffective = "shred_ineffective"
+	// ErrorAppRunning marks an option left alone because its app is running,
+	// or because the running programs couldn't be listed
+	ErrorAppRunning = "app_running"
+)
+
+// maxErrorsPerItem caps the errors reported for one item so a broken folder
+// with thousands of entries doesn't flood the response
+const maxErrorsPerItem = 100
+
+// maxErrorSamples caps the sample paths kept per error kind
+const maxErrorSamples = 5
+
+// classifyError maps a discovery error to the kind reported to the client
+func classifyError(err error) string {
+	switch {
+	case isInUse(err):
+		return ErrorInUse
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorPermissionDenied
+	case errors.Is(err, filepath.ErrBadPattern):
+		return ErrorGlobSyntax
+	default:
+		return ErrorWalkFailed
+	}
+}
+
+// classifyDeleteError maps the error of deleting or truncating a file
+func classifyDeleteError(err error, info fs.FileInfo) string {
+	switch {
+	case isInUse(err):
+		return ErrorInUse
+	case isReadOnly(err, info):
+		return ErrorReadOnly
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorPermissionDenied
+	default:
+		return ErrorDeleteFailed
+	}
+}
+
+// errorCollector gathers errors from concurrent visitors. Every error is
+// counted per kind; the first maxErrorsPerItem are kept in full.
+type errorCollector struct {
+	mutex   sync.Mutex
+	errors  []models.ItemError
+	summary []models.ErrorSummary
+}
+
+// add records the discovery error met at path, it matches Visitor.Error
+func (collector *errorCollector) add(path string, err error) {
+	collector.addKind(classifyError(err), path, err)
+}
+
+// addKind records an error of the given kind
+func (collector *errorCollector) addKind(kind string, path string, err error) {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	if len(collector.errors) < maxErrorsPerItem {
+		collector.errors = append(collector.errors, models.ItemError{
+			Kind:    kind,
+			Path:    path,
+			Message: err.Error(),
+		})
+	}
+	collector.summary = addToSummary(collector.summary, kind, 1, []string{path})
+}
+
+// list returns the collected errors
+func (collector *errorCollector) list() []models.ItemError {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	return collector.errors
+}
+
+// summarize returns the error counts per kind
+func (collector *errorCollector) summarize() []models.ErrorSummary {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	return collector.summary
+}
+
+// addToSummary adds count errors of kind with their sample paths, keeping
+// kinds in the order they were first met
+func addToSummary(summary []models.ErrorSummary, kind string, count uint64, samples []string) []models.ErrorSummary {
+	i := 0
+	for i < len(summary) && summary[i].Kind != kind {
+		i++
+	}
+	if i == len(summary) {
+		summary = append(summary, models.ErrorSummary{Kind: kind})
+	}
+
+	summary[i].Count += count
+	for _, sample := range samples {
+		if len(summary[i].Samples) >= maxErrorSamples {
+			break
+		}
+		summary[i].Samples = append(summary[i].Samples, sample)
+	}
+	return summary
+}
+
+// mergeSummaries adds the counts and samples of from to into
+func mergeSummaries(into []models.ErrorSummary, from []models.ErrorSummary) []models.ErrorSummary {
+	for _, group := range from {
+		into = addToSummary(into, group.Kind, group.Count, group.Samples)
+	}
+	return into
+}
+
+// unknownIDError describes why a request doesn't match any cleaner option,
+// ok is false when it does
+func unknownIDError(request models.CleanRequest,
+	cleanerMap map[string]map[string][]models.Action) (models.ItemError, bool) {
+	options, ok := cleanerMap[request.CleanerID]
+	if !ok {
+		return models.ItemError{
+			Kind:    ErrorUnknownCleaner,
+			Message: fmt.Sprintf("cleaner %q doesn't exist or isn't available", request.CleanerID),
+		}, true
+	}
+	if _, ok := options[request.OptionID]; !ok {
+		return models.ItemError{
+			Kind:    ErrorUnknownOption,
+			Message: fmt.Sprintf("option %q of cleaner %q doesn't exist or isn't available", request.OptionID, request.CleanerID),
+		}, true
+	}
+	return models.ItemError{}, false
+}
+
+// UnknownRequests returns the requests that don't match any cleaner option
+func UnknownRequests(requests []models.CleanRequest,
+	cleanerMap map[string]map[string][]models.Action) []models.CleanRequest {
+	var unknown []models.CleanRequest
+	for _, request := range requests {
+		if _, ok := unknownIDError(request, cleanerMap); ok {
+			unknown = append(unknown, request)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package service
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// isInUse reports whether err means the file is busy, e.g. a running executable
+func isInUse(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ETXTBSY)
+}
+
+// isReadOnly reports whether err was caused by a read-only file system. The
+// permission bits of the file itself don't decide whether it can be deleted here.
+func isReadOnly(err error, _ fs.FileInfo) bool {
+	return errors.Is(err, syscall.EROFS)
+}
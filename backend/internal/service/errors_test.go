@@ -0,0 +1,22 @@
+package service
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestErrorCollectorCaps(t *testing.T) {
+	var errs errorCollector
+	for i := 0; i < 2*maxErrorsPerItem; i++ {
+		errs.add(fmt.Sprintf("/data/%d", i), fs.ErrPermission)
+	}
+
+	if n := len(errs.list()); n != maxErrorsPerItem {
+		t.Errorf("kept %d errors, want %d", n, maxErrorsPerItem)
+	}
+	summary := errs.summarize()
+	if len(summary) != 1 || summary[0].Count != 2*maxErrorsPerItem || len(summary[0].Samples) != maxErrorSamples {
+		t.Errorf("got summary %+v", summary)
+	}
+}
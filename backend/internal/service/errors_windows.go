@@ -0,0 +1,19 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+
+	"golang.org/x/sys/windows"
+)
+
+// isInUse reports whether err means another process holds the file open
+func isInUse(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+// isReadOnly reports whether err was caused by the file's read-only attribute,
+// which Go reports as a missing write permission
+func isReadOnly(err error, info fs.FileInfo) bool {
+	return errors.Is(err, fs.ErrPermission) && info != nil && info.Mode().Perm()&0o200 == 0
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"backend/internal/detector"
+	"backend/internal/models"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// PathFilter decides which discovered paths an action may touch,
+// based on the action's Include, Regex and Exclude settings, its walk depth
+// and its file size limits.
+type PathFilter struct {
+	include []string
+	regex   *regexp.Regexp
+	invalid bool // the action's regex doesn't compile, so nothing matches
+	exclude []string
+	depth   int // 0 walks the whole tree
+	minSize uint64
+	maxSize uint64 // 0 means no upper limit
+}
+
+// NewPathFilter builds the filter for an action, expanding environment
+// variables in its exclusions the same way as in the action path.
+func NewPathFilter(action models.Action) PathFilter {
+	filter := PathFilter{
+		include: action.Include,
+		depth:   action.Depth,
+		minSize: uint64(action.MinSize),
+		maxSize: uint64(action.MaxSize),
+	}
+	if action.Regex != "" {
+		regex, err := CompileNameRegex(action.Regex)
+		filter.regex, filter.invalid = regex, err != nil
+	}
+	for _, exclude := range action.Exclude {
+		filter.exclude = append(filter.exclude, filepath.Clean(detector.ExpandPath(exclude)))
+	}
+	return filter
+}
+
+// AllowsFile reports whether the file at path should be counted:
+// it must not be excluded, and its name must match one of the include patterns
+// and the regex, when the action has them.
+func (f PathFilter) AllowsFile(path string) bool {
+	return f.matches(path)
+}
+
+// AllowsDir reports whether the folder at path is matched as a whole by
+// "walk.dirs" and "walk.top", by the same rules as AllowsFile
+func (f PathFilter) AllowsDir(path string) bool {
+	return f.matches(path)
+}
+
+// AllowsSize reports whether a file of size bytes is within the size limits
+func (f PathFilter) AllowsSize(size int64) bool {
+	return uint64(size) >= f.minSize && (f.maxSize == 0 || uint64(size) <= f.maxSize)
+}
+
+// sized passes to visit only the files within the size limits
+func (f PathFilter) sized(visit Visitor) Visitor {
+	if f.minSize == 0 && f.maxSize == 0 {
+		return visit
+	}
+
+	file := visit.File
+	visit.File = func(path string, info fs.FileInfo) {
+		if f.AllowsSize(info.Size()) {
+			file(path, info)
+		}
+	}
+	return visit
+}
+
+// Descends reports whether a walk enters a folder depth levels below its
+// root, where the root's children are at depth 1
+func (f PathFilter) Descends(depth int) bool {
+	return f.depth == 0 || depth < f.depth
+}
+
+// contents is the filter for everything inside a folder matched as a whole:
+// only the exclusions still apply
+func (f PathFilter) contents() PathFilter {
+	return PathFilter{exclude: f.exclude}
+}
+
+// withDepth returns the filter limited to depth levels below the walk root
+func (f PathFilter) withDepth(depth int) PathFilter {
+	f.depth = depth
+	return f
+}
+
+func (f PathFilter) matches(path string) bool {
+	if f.invalid || f.Excludes(path) {
+		return false
+	}
+
+	name := filepath.Base(path)
+	if f.regex != nil && !f.regex.MatchString(name) {
+		return false
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.include {
+		if matchPath(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Excludes reports whether path, or any directory above it, matches an exclusion.
+func (f PathFilter) Excludes(path string) bool {
+	if len(f.exclude) == 0 {
+		return false
+	}
+
+	for current := filepath.Clean(path); ; {
+		for _, pattern := range f.exclude {
+			if matchPath(pattern, current) {
+				return true
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return false
+		}
+		current = parent
+	}
+}
+
+// CompileNameRegex compiles an action's file name regex, ignoring case on
+// Windows like the other name patterns
+func CompileNameRegex(expr string) (*regexp.Regexp, error) {
+	if runtime.GOOS == "windows" {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// walkDepth returns how many levels below root path is, 1 for its children
+func walkDepth(root string, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// matchPath matches a filepath.Match pattern, ignoring case on Windows
+func matchPath(pattern, path string) bool {
+	if runtime.GOOS == "windows" {
+		pattern = strings.ToLower(pattern)
+		path = strings.ToLower(path)
+	}
+
+	matched, err := filepath.Match(pattern, path)
+	return err == nil && matched
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"backend/internal/constants"
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// root is the top of the synthetic trees, an absolute path on every OS
+var root = filepath.Join(string(filepath.Separator), "data")
+
+// useMemoryFS swaps in an empty in-memory file system for the test
+func useMemoryFS(tb testing.TB) *filesystem.Memory {
+	tb.Helper()
+
+	memory := filesystem.NewMemory()
+	useFS(tb, memory)
+	return memory
+}
+
+func useFS(tb testing.TB, fsys filesystem.MutableFS) {
+	tb.Helper()
+
+	previous := filesystem.Set(fsys)
+	tb.Cleanup(func() { filesystem.Set(previous) })
+}
+
+// useMemoryRegistry swaps in an empty in-memory registry for the test
+func useMemoryRegistry(tb testing.TB) *detector.MemoryRegistry {
+	tb.Helper()
+
+	registry := detector.NewMemoryRegistry()
+	previous := detector.SetRegistry(registry)
+	tb.Cleanup(func() { detector.SetRegistry(previous) })
+	return registry
+}
+
+// useProcesses pretends that lister's processes are the ones running for the test
+func useProcesses(tb testing.TB, lister detector.ProcessLister) {
+	tb.Helper()
+
+	previous := detector.SetProcesses(lister)
+	tb.Cleanup(func() { detector.SetProcesses(previous) })
+}
+
+// useBackupDir points registry backups at dir for the test
+func useBackupDir(tb testing.TB, dir string) {
+	tb.Helper()
+
+	previous := constants.RegistryBackupDir
+	constants.RegistryBackupDir = dir
+	tb.Cleanup(func() { constants.RegistryBackupDir = previous })
+}
+
+// buildTree writes files files of size bytes under dir, perDir to a folder.
+// Every fourth file is a .log, the rest are .tmp.
+func buildTree(memory *filesystem.Memory, dir string, files int, perDir int, size int) {
+	data := []byte(strings.Repeat("x", size))
+	for i := 0; i < files; i++ {
+		ext := ".tmp"
+		if i%4 == 0 {
+			ext = ".log"
+		}
+		name := filepath.Join(dir, fmt.Sprintf("d%05d", i/perDir), fmt.Sprintf("f%07d%s", i, ext))
+		memory.WriteFile(name, data)
+	}
+}
+
+// newTree returns an in-memory tree of dirs folders under root with perDir
+// one-byte files each, without installing it
+func newTree(dirs int, perDir int) *filesystem.Memory {
+	memory := filesystem.NewMemory()
+	buildTree(memory, root, dirs*perDir, perDir, 1)
+	return memory
+}
+
+// hookFS calls onReadDir before every directory listing, to slow the walk
+// down or cancel it part way through
+type hookFS struct {
+	*filesystem.Memory
+	onReadDir func(name string)
+}
+
+func (h hookFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	h.onReadDir(name)
+	return h.Memory.ReadDir(name)
+}
+
+// testPath joins elements under root
+func testPath(elements ...string) string {
+	return filepath.Join(append([]string{root}, elements...)...)
+}
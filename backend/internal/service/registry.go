@@ -0,0 +1,82 @@
+package service
+
+import (
+	"backend/internal/constants"
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// IsRegistryCommand reports whether an action command works on the registry instead of files
+func IsRegistryCommand(command string) bool {
+	return command == models.RegistryDeleteKey || command == models.RegistryDeleteValue
+}
+
+// RegistryTargets lists what a registry action would delete: the key itself
+// for registry.delete_key, or every value of the key whose name matches the
+// action's Value pattern (case-insensitive) for registry.delete_value.
+// The default value is never matched by a pattern.
+func RegistryTargets(action models.Action) []models.RegistryEntry {
+	registry := detector.Registry()
+
+	switch action.Command {
+	case models.RegistryDeleteKey:
+		if registry.KeyExists(action.Path) {
+			return []models.RegistryEntry{{Key: action.Path}}
+		}
+	case models.RegistryDeleteValue:
+		values, err := registry.Values(action.Path)
+		if err != nil {
+			return nil
+		}
+
+		pattern := strings.ToLower(action.Value)
+		var targets []models.RegistryEntry
+		for _, value := range values {
+			if value.Name == "" {
+				continue
+			}
+			if matched, err := path.Match(pattern, strings.ToLower(value.Name)); err == nil && matched {
+				targets = append(targets, models.RegistryEntry{Key: action.Path, Value: value.Name})
+			}
+		}
+		return targets
+	}
+
+	return nil
+}
+
+// DeleteRegistryEntry deletes a key with everything beneath it, or a single value
+func DeleteRegistryEntry(entry models.RegistryEntry) error {
+	if entry.Value == "" {
+		return detector.Registry().DeleteKey(entry.Key)
+	}
+	return detector.Registry().DeleteValue(entry.Key, entry.Value)
+}
+
+// BackupRegistry exports the entries to a new .reg file in the backup
+// directory and returns its path
+func BackupRegistry(entries []models.RegistryEntry) (string, error) {
+	fsys := filesystem.Current()
+	if err := fsys.MkdirAll(constants.RegistryBackupDir); err != nil {
+		return "", err
+	}
+
+	pattern := fmt.Sprintf("registry-%s-*.reg", time.Now().Format("20060102-150405.000"))
+	file, err := fsys.CreateTemp(constants.RegistryBackupDir, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if err := detector.ExportRegistry(file, detector.Registry(), entries); err != nil {
+		file.Close()
+		fsys.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), file.Close()
+}
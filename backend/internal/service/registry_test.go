@@ -0,0 +1,53 @@
+package service
+
+import (
+	"backend/internal/detector"
+	"backend/internal/models"
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupRegistry(t *testing.T) {
+	memory := useMemoryFS(t)
+	registry := useMemoryRegistry(t)
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("MRU1", "a"))
+	useBackupDir(t, testPath("backups"))
+
+	entries := []models.RegistryEntry{{Key: `HKCU\Software\App\Recent`, Value: "MRU1"}}
+	backupPath, err := BackupRegistry(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(backupPath) != testPath("backups") || filepath.Ext(backupPath) != ".reg" {
+		t.Errorf("backup written to %q", backupPath)
+	}
+
+	var want bytes.Buffer
+	if err := detector.ExportRegistry(&want, registry, entries); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := memory.ReadFile(backupPath); err != nil || !bytes.Equal(data, want.Bytes()) {
+		t.Errorf("backup content differs from the export: %v", err)
+	}
+
+	second, err := BackupRegistry(entries)
+	if err != nil || second == backupPath {
+		t.Errorf("second backup %q, %v: want a new file", second, err)
+	}
+}
+
+func TestBackupRegistryRemovesPartialFile(t *testing.T) {
+	memory := useMemoryFS(t)
+	registry := useMemoryRegistry(t)
+	registry.SetValue(`HKCU\Software\App\Recent`, detector.StringValue("MRU1", "a"))
+	useBackupDir(t, testPath("backups"))
+	memory.SetCapacity(4)
+
+	if _, err := BackupRegistry([]models.RegistryEntry{{Key: `HKCU\Software\App\Recent`}}); err == nil {
+		t.Fatal("backup succeeded on a full volume")
+	}
+	if entries, err := memory.ReadDir(testPath("backups")); err != nil || len(entries) != 0 {
+		t.Errorf("got %d files left in the backup directory, %v", len(entries), err)
+	}
+}
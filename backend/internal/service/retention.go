@@ -0,0 +1,66 @@
+package service
+
+import (
+	"backend/internal/models"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// retention holds back the newest files an action matches, for actions with
+// "keep_newest" or "keep_total_size". Discovery hands it every match with its
+// metadata; only once the whole action has been discovered can it tell which
+// files are the excess.
+type retention struct {
+	keepNewest int
+	keepSize   uint64
+
+	mutex sync.Mutex
+	files []matchedFile
+}
+
+type matchedFile struct {
+	path string
+	info fs.FileInfo
+}
+
+// newRetention returns the retention rules of action, or nil if it has none
+func newRetention(action models.Action) *retention {
+	if action.KeepNewest <= 0 && action.KeepTotalSize == 0 {
+		return nil
+	}
+	return &retention{keepNewest: action.KeepNewest, keepSize: uint64(action.KeepTotalSize)}
+}
+
+// add records a discovered file; it's safe for concurrent use
+func (r *retention) add(path string, info fs.FileInfo) {
+	r.mutex.Lock()
+	r.files = append(r.files, matchedFile{path: path, info: info})
+	r.mutex.Unlock()
+}
+
+// excess sorts the recorded files newest first and returns those beyond the
+// limits, newest first. The kept files are the longest run of newest files
+// within both limits, so an older file is never kept over a newer one.
+// Files modified at the same time are ordered by path.
+func (r *retention) excess() []matchedFile {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	slices.SortFunc(r.files, func(a, b matchedFile) int {
+		if c := b.info.ModTime().Compare(a.info.ModTime()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.path, b.path)
+	})
+
+	var total uint64
+	for i, file := range r.files {
+		total += uint64(file.info.Size())
+		if (r.keepNewest > 0 && i >= r.keepNewest) || (r.keepSize > 0 && total > r.keepSize) {
+			return r.files[i:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"crypto/rand"
+	"io/fs"
+)
+
+// shredPasses returns how many times a "shred" action overwrites a file
+func shredPasses(action models.Action) int {
+	return max(action.Passes, 1)
+}
+
+// fillFunc returns the function filling the blocks written over data:
+// zeros for models.ShredFillZero, random bytes otherwise
+func fillFunc(fill string) func(block []byte) {
+	if fill == models.ShredFillZero {
+		return func(block []byte) { clear(block) }
+	}
+	return func(block []byte) { rand.Read(block) }
+}
+
+// shredCost returns the bytes shredding the file at path writes and why the
+// overwrite would likely miss its data, "" if nothing suggests so. Symbolic
+// links and files with other hard links cost nothing: only the name is deleted.
+func shredCost(action models.Action, path string, info fs.FileInfo) (uint64, string) {
+	if isSymlink(path) || filesystem.LinkCount(path, info) > 1 {
+		return 0, ""
+	}
+	return uint64(info.Size()) * uint64(shredPasses(action)), filesystem.OverwriteIneffective(path, info)
+}
+
+// shredFile overwrites the file at path as the action says and deletes it.
+// overwritten is false for symbolic links, which are deleted without touching
+// their target, and for files with other hard links, which are only unlinked
+// so the data the other names still show is kept. ineffective tells why the
+// overwrite likely missed the data. A file that can't be overwritten is left
+// in place.
+func shredFile(action models.Action, path string, info fs.FileInfo) (overwritten bool, ineffective string, err error) {
+	fsys := filesystem.Current()
+	// checked now rather than at discovery, as removing an earlier match may
+	// have dropped the other links
+	current, err := fsys.Lstat(path)
+	if err != nil {
+		return false, "", err
+	}
+	if current.Mode()&fs.ModeSymlink != 0 || filesystem.LinkCount(path, current) > 1 {
+		return false, "", fsys.Remove(path)
+	}
+
+	ineffective = filesystem.OverwriteIneffective(path, info)
+	fill := fillFunc(action.Fill)
+	for pass := 0; pass < shredPasses(action); pass++ {
+		if err := fsys.Overwrite(path, fill); err != nil {
+			return false, "", err
+		}
+	}
+	return true, ineffective, fsys.Remove(path)
+}
+
+// isSymlink reports whether path itself is a symbolic link
+func isSymlink(path string) bool {
+	info, err := filesystem.Current().Lstat(path)
+	return err == nil && info.Mode()&fs.ModeSymlink != 0
+}
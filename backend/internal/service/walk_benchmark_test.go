@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkProcessWalkAction walks synthetic in-memory trees, so it measures
+// the discovery pipeline rather than the disk
+func BenchmarkProcessWalkAction(b *testing.B) {
+	for _, files := range []int{10_000, 100_000, 1_000_000} {
+		b.Run(fmt.Sprintf("%d files", files), func(b *testing.B) {
+			memory := useMemoryFS(b)
+			buildTree(memory, root, files, 1000, 1)
+			filter := PathFilter{}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var count atomic.Uint64
+				ProcessWalkAction(context.Background(), root, filter, Visitor{File: func(string, fs.FileInfo) { count.Add(1) }})
+
+				if count.Load() != uint64(files) {
+					b.Fatalf("visited %d files, want %d", count.Load(), files)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"backend/internal/constants"
+	"backend/internal/detector"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sync"
+)
+
+var (
+	// ErrWipeRunning is returned by WipeFreeSpace while another wipe is running
+	ErrWipeRunning = errors.New("a free space wipe is already running")
+	// ErrWipeMargin is returned by WipeFreeSpace when the free space doesn't exceed the safety margin
+	ErrWipeMargin = errors.New("free space is within the safety margin")
+	// ErrNotDirectory is returned by WipeFreeSpace when the path isn't a folder
+	ErrNotDirectory = errors.New("not a directory")
+)
+
+// wipeBlockSize is how much a wipe writes at a time
+const wipeBlockSize = 1 << 20
+
+// wipeCheckInterval is how many bytes a wipe writes between checks of the
+// free space, which other programs may be using up meanwhile
+const wipeCheckInterval = 256 << 20
+
+// maxWipeFileSize caps each temporary file below the FAT32 limit of 4 GiB;
+// a wipe writes as many files as it needs
+var maxWipeFileSize uint64 = 1<<32 - wipeBlockSize
+
+// wipeTracker holds the progress of the running or last wipe
+type wipeTracker struct {
+	mutex    sync.Mutex
+	progress models.WipeProgress
+}
+
+var wipes = &wipeTracker{}
+
+// start marks a wipe of path as running, ErrWipeRunning if one already is
+func (tracker *wipeTracker) start(path string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if tracker.progress.Running {
+		return ErrWipeRunning
+	}
+	tracker.progress = models.WipeProgress{Running: true, Path: path}
+	return nil
+}
+
+func (tracker *wipeTracker) update(target uint64, written uint64) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.progress.Target, tracker.progress.Written = target, written
+}
+
+func (tracker *wipeTracker) finish() {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.progress.Running = false
+}
+
+// GetWipeProgress returns the progress of the running or last free space wipe
+func GetWipeProgress() models.WipeProgress {
+	wipes.mutex.Lock()
+	defer wipes.mutex.Unlock()
+	return wipes.progress
+}
+
+// WipeFreeSpace overwrites the free space of the volume holding the request's
+// folder: it fills temporary files in that folder with zeros or random data,
+// flushes them to the device and deletes them.
+//
+// The wipe stops short of the safety margin, the larger of the request's and
+// constants.WipeFreeSpaceMargin, and lowers its target when other programs
+// use up free space meanwhile. Only one wipe runs at a time; its progress is
+// reported by GetWipeProgress. On cancellation the files are deleted and the
+// result so far is returned together with the context error.
+func WipeFreeSpace(ctx context.Context, request models.WipeRequest) (*models.WipeResult, error) {
+	wipe, err := StartWipe(request)
+	if err != nil {
+		return nil, err
+	}
+	return wipe(ctx)
+}
+
+// StartWipe claims the wipe slot for the request's folder, ErrWipeRunning
+// while another wipe is running, and returns the function running the wipe
+// like WipeFreeSpace. That function frees the slot and must be called once.
+func StartWipe(request models.WipeRequest) (func(ctx context.Context) (*models.WipeResult, error), error) {
+	dir := detector.ExpandPath(request.Path)
+	if err := wipes.start(dir); err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (*models.WipeResult, error) {
+		defer wipes.finish()
+		return wipeFreeSpace(ctx, dir, request)
+	}, nil
+}
+
+// wipeFreeSpace runs a wipe of dir once it holds the wipe slot
+func wipeFreeSpace(ctx context.Context, dir string, request models.WipeRequest) (*models.WipeResult, error) {
+	fsys := filesystem.Current()
+	info, err := fsys.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "wipe", Path: dir, Err: ErrNotDirectory}
+	}
+
+	free, err := fsys.FreeSpace(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.WipeResult{
+		Path:       dir,
+		FreeBefore: free,
+		Margin:     max(uint64(request.Margin), constants.WipeFreeSpaceMargin),
+	}
+	if free <= result.Margin {
+		return result, ErrWipeMargin
+	}
+	result.Target = free - result.Margin
+	wipes.update(result.Target, 0)
+
+	slog.Info("Wiping free space", "path", dir, "target", result.Target, "margin", result.Margin)
+	files, err := fillFreeSpace(ctx, fsys, dir, fillFunc(request.Fill), result)
+	result.Files = len(files)
+
+	for _, name := range files {
+		if removeErr := fsys.Remove(name); removeErr != nil {
+			slog.Error("Error removing wipe file", "path", name, "error", removeErr)
+			if err == nil {
+				err = fmt.Errorf("removing %s: %w", name, removeErr)
+			}
+		}
+	}
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return result, err
+}
+
+// fillFreeSpace writes result.Target bytes into temporary files in dir and
+// returns the files it created, also on error
+func fillFreeSpace(ctx context.Context, fsys filesystem.MutableFS, dir string,
+	fill func(block []byte), result *models.WipeResult) ([]string, error) {
+	var files []string
+	var file filesystem.WritableFile
+	var fileSize, sinceCheck uint64
+	block := make([]byte, wipeBlockSize)
+
+	// closeFile flushes the current file so its data reaches the device
+	// before it is deleted
+	closeFile := func() error {
+		if file == nil {
+			return nil
+		}
+		err := file.Sync()
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		file = nil
+		return err
+	}
+
+	for result.Written < result.Target && ctx.Err() == nil {
+		if sinceCheck >= wipeCheckInterval {
+			sinceCheck = 0
+			if free, err := fsys.FreeSpace(dir); err == nil {
+				if free <= result.Margin {
+					result.Target = result.Written
+					break
+				}
+				result.Target = min(result.Target, result.Written+free-result.Margin)
+			}
+		}
+
+		chunk := block[:min(wipeBlockSize, result.Target-result.Written)]
+		if file == nil || fileSize+uint64(len(chunk)) > maxWipeFileSize {
+			if err := closeFile(); err != nil {
+				return files, err
+			}
+			created, err := fsys.CreateTemp(dir, ".wipe-*.tmp")
+			if err != nil {
+				return files, err
+			}
+			file, fileSize = created, 0
+			files = append(files, created.Name())
+		}
+
+		fill(chunk)
+		n, err := file.Write(chunk)
+		result.Written += uint64(n)
+		fileSize += uint64(n)
+		sinceCheck += uint64(n)
+		wipes.update(result.Target, result.Written)
+		if err != nil {
+			closeFile()
+			return files, err
+		}
+	}
+
+	return files, closeFile()
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"backend/internal/constants"
+	"backend/internal/filesystem"
+	"backend/internal/models"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+// removalFS keeps the contents of the files it removes
+type removalFS struct {
+	*filesystem.Memory
+	removed map[string][]byte
+}
+
+func (r removalFS) Remove(name string) error {
+	data, _ := r.Memory.ReadFile(name)
+	r.removed[name] = data
+	return r.Memory.Remove(name)
+}
+
+// useWipeLimits sets the safety margin and the size of the wipe files for the test
+func useWipeLimits(t *testing.T, margin uint64, fileSize uint64) {
+	previousMargin, previousSize := constants.WipeFreeSpaceMargin, maxWipeFileSize
+	constants.WipeFreeSpaceMargin, maxWipeFileSize = margin, fileSize
+	t.Cleanup(func() { constants.WipeFreeSpaceMargin, maxWipeFileSize = previousMargin, previousSize })
+}
+
+func TestWipeFreeSpace(t *testing.T) {
+	useWipeLimits(t, 2<<20, 3<<20)
+	memory := filesystem.NewMemory()
+	memory.SetCapacity(10 << 20)
+	memory.WriteFile(testPath("volume", "existing"), make([]byte, 1<<20))
+	fsys := removalFS{Memory: memory, removed: make(map[string][]byte)}
+	useFS(t, fsys)
+
+	result, err := WipeFreeSpace(context.Background(), models.WipeRequest{Path: testPath("volume"), Fill: models.ShredFillZero})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.FreeBefore != 9<<20 || result.Target != 7<<20 || result.Written != 7<<20 || result.Files != 3 {
+		t.Errorf("got %+v", *result)
+	}
+	var removed uint64
+	for name, data := range fsys.removed {
+		if !bytes.Equal(data, make([]byte, len(data))) {
+			t.Errorf("%s wasn't filled with zeros", name)
+		}
+		removed += uint64(len(data))
+	}
+	if len(fsys.removed) != 3 || removed != 7<<20 {
+		t.Errorf("removed %d files of %d bytes", len(fsys.removed), removed)
+	}
+	if free, _ := memory.FreeSpace(testPath("volume")); free != 9<<20 {
+		t.Errorf("%d bytes free after the wipe", free)
+	}
+
+	if progress := GetWipeProgress(); progress.Running || progress.Written != 7<<20 || progress.Target != 7<<20 {
+		t.Errorf("progress: %+v", progress)
+	}
+}
+
+func TestWipeFreeSpaceMargin(t *testing.T) {
+	useWipeLimits(t, 1<<20, maxWipeFileSize)
+	memory := useMemoryFS(t)
+	memory.SetCapacity(3 << 20)
+	memory.MkdirAll(testPath("volume"))
+
+	result, err := WipeFreeSpace(context.Background(), models.WipeRequest{Path: testPath("volume"), Margin: 4 << 20})
+	if !errors.Is(err, ErrWipeMargin) || result.Margin != 4<<20 || result.Written != 0 {
+		t.Errorf("got %+v, %v", result, err)
+	}
+
+	memory.WriteFile(testPath("volume", "file"), nil)
+	if _, err := WipeFreeSpace(context.Background(), models.WipeRequest{Path: testPath("volume", "file")}); !errors.Is(err, ErrNotDirectory) {
+		t.Errorf("wiping from a file: %v", err)
+	}
+}
+
+func TestWipeFreeSpaceCancelled(t *testing.T) {
+	useWipeLimits(t, 1<<20, maxWipeFileSize)
+	memory := useMemoryFS(t)
+	memory.SetCapacity(3 << 20)
+	memory.MkdirAll(testPath("volume"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := WipeFreeSpace(ctx, models.WipeRequest{Path: testPath("volume")})
+	if !errors.Is(err, context.Canceled) || result.Written != 0 {
+		t.Errorf("got %+v, %v", result, err)
+	}
+	if entries, _ := memory.ReadDir(testPath("volume")); len(entries) != 0 {
+		t.Errorf("%d files left behind", len(entries))
+	}
+}
+
+func TestWipeFreeSpaceRunning(t *testing.T) {
+	useWipeLimits(t, 1<<20, 4<<20)
+	memory := useMemoryFS(t)
+	memory.MkdirAll(testPath("volume"))
+	memory.SetCapacity(8 << 20)
+	wipe, err := StartWipe(models.WipeRequest{Path: testPath("volume")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress := GetWipeProgress(); !progress.Running || progress.Path != testPath("volume") {
+		t.Errorf("progress after start: %+v", progress)
+	}
+
+	if _, err := StartWipe(models.WipeRequest{Path: testPath("volume")}); !errors.Is(err, ErrWipeRunning) {
+		t.Errorf("second start: got %v", err)
+	}
+	if _, err := WipeFreeSpace(context.Background(), models.WipeRequest{Path: testPath("volume")}); !errors.Is(err, ErrWipeRunning) {
+		t.Errorf("second wipe: got %v", err)
+	}
+
+	if _, err := wipe(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if GetWipeProgress().Running {
+		t.Error("wipe slot not freed")
+	}
+	if _, err := StartWipe(models.WipeRequest{Path: testPath("volume")}); err != nil {
+		t.Errorf("start after the wipe: %v", err)
+	} else {
+		wipes.finish()
+	}
+}